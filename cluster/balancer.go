@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// Balancer picks a replica for a read among eligible nodes.
+// Pick is called with at least one node and returns index of the chosen one.
+type Balancer interface {
+	Pick(nodes []Node) int
+}
+
+// WeightedRoundRobin returns a Balancer distributing reads proportionally to node weights,
+// see WithWeights. Nodes with non-positive weight are treated as having weight 1.
+func WeightedRoundRobin() Balancer {
+	return &weightedRoundRobin{current: make(map[string]int)}
+}
+
+// LeastConnections returns a Balancer choosing the node with the fewest acquired connections.
+func LeastConnections() Balancer {
+	return &leastConnections{}
+}
+
+// LowestLatency returns a Balancer choosing between two random nodes the one
+// with the lower moving average of observed query durations.
+// Random choice keeps nodes with stale high latency getting a share of reads to recover.
+func LowestLatency() Balancer {
+	return lowestLatency{}
+}
+
+// weightedRoundRobin implements smooth weighted round-robin,
+// it interleaves nodes instead of sending bursts to the heaviest one.
+type weightedRoundRobin struct {
+	mu      sync.Mutex
+	current map[string]int
+}
+
+func (b *weightedRoundRobin) Pick(nodes []Node) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	best, total := 0, 0
+	for i, node := range nodes {
+		weight := max(node.Weight(), 1)
+		total += weight
+		b.current[node.Name()] += weight
+
+		if b.current[node.Name()] > b.current[nodes[best].Name()] {
+			best = i
+		}
+	}
+
+	b.current[nodes[best].Name()] -= total
+	return best
+}
+
+type leastConnections struct {
+	count atomic.Uint64
+}
+
+func (b *leastConnections) Pick(nodes []Node) int {
+	// start from a rotating offset so ties are spread among nodes
+	start := int(b.count.Add(1) % uint64(len(nodes)))
+	best, bestConns := start, nodes[start].AcquiredConns()
+	for i := 1; i < len(nodes); i++ {
+		j := (start + i) % len(nodes)
+		if conns := nodes[j].AcquiredConns(); conns < bestConns {
+			best, bestConns = j, conns
+		}
+	}
+
+	return best
+}
+
+type lowestLatency struct{}
+
+func (lowestLatency) Pick(nodes []Node) int {
+	if len(nodes) == 1 {
+		return 0
+	}
+
+	i := rand.IntN(len(nodes))                          //nolint: gosec // Balancing doesn't need secure random.
+	j := (i + 1 + rand.IntN(len(nodes)-1)) % len(nodes) //nolint: gosec // Balancing doesn't need secure random.
+	if nodes[j].Latency() < nodes[i].Latency() {
+		return j
+	}
+
+	return i
+}
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matryer/is"
+)
+
+type testNode struct {
+	name     string
+	weight   int
+	acquired int32
+	latency  time.Duration
+}
+
+func (n *testNode) Name() string           { return n.name }
+func (n *testNode) Weight() int            { return n.weight }
+func (n *testNode) Stat() *pgxpool.Stat    { return nil }
+func (n *testNode) AcquiredConns() int32   { return n.acquired }
+func (n *testNode) Latency() time.Duration { return n.latency }
+
+func TestWeightedRoundRobin(t *testing.T) {
+	is := is.New(t)
+
+	nodes := []Node{
+		&testNode{name: "a", weight: 5},
+		&testNode{name: "b", weight: 1},
+		&testNode{name: "c", weight: 0},
+	}
+	b := WeightedRoundRobin()
+
+	picks := make([]int, 0, 14)
+	for range 14 {
+		picks = append(picks, b.Pick(nodes))
+	}
+
+	counts := make(map[int]int)
+	for _, p := range picks {
+		counts[p]++
+	}
+	is.Equal(counts, map[int]int{0: 10, 1: 2, 2: 2}) // picks are proportional to weights
+	is.Equal(picks[:7], []int{0, 0, 1, 0, 2, 0, 0})  // heavy node is interleaved with others
+}
+
+func TestLeastConnections(t *testing.T) {
+	is := is.New(t)
+
+	b := LeastConnections()
+	nodes := []Node{
+		&testNode{name: "a", acquired: 3},
+		&testNode{name: "b", acquired: 1},
+		&testNode{name: "c", acquired: 2},
+	}
+	for range 5 {
+		is.Equal(b.Pick(nodes), 1) // node with the fewest acquired connections
+	}
+
+	idle := []Node{&testNode{name: "a"}, &testNode{name: "b"}}
+	is.True(b.Pick(idle) != b.Pick(idle)) // ties are spread among nodes
+}
+
+func TestLowestLatency(t *testing.T) {
+	is := is.New(t)
+
+	b := LowestLatency()
+	is.Equal(b.Pick([]Node{&testNode{name: "a"}}), 0)
+
+	nodes := []Node{
+		&testNode{name: "a", latency: 10 * time.Millisecond},
+		&testNode{name: "b", latency: time.Millisecond},
+	}
+	for range 10 {
+		is.Equal(b.Pick(nodes), 1) // faster of two nodes
+	}
+}
+
+func TestObserveLatency(t *testing.T) {
+	is := is.New(t)
+
+	db := &pdb{}
+	is.Equal(db.Latency(), time.Duration(0))
+
+	db.observeLatency(100 * time.Millisecond)
+	is.Equal(db.Latency(), 100*time.Millisecond) // first observation is taken as is
+
+	db.observeLatency(0)
+	is.Equal(db.Latency(), 70*time.Millisecond) // moving average decays towards new observations
+}
+
+func TestPickReplicaWithBalancer(t *testing.T) {
+	is := is.New(t)
+
+	primary := &pdb{name: "primary"}
+	slow := &pdb{name: "slow", weight: 1}
+	fast := &pdb{name: "fast", weight: 1}
+	slow.latency.Store(int64(time.Second))
+	fast.latency.Store(int64(time.Millisecond))
+
+	db := &Cluster{pdbs: []*pdb{primary, slow, fast}, balancer: LowestLatency()}
+	for range 10 {
+		is.Equal(db.pickReplica(), fast)
+	}
+
+	fast.unhealthy.Store(true)
+	slow.unhealthy.Store(true)
+	is.Equal(db.pickReplica(), primary) // balancer is not consulted without replicas
+}
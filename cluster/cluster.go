@@ -3,10 +3,13 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/MrEhbr/pgxext/v2/conn"
 	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,33 +21,55 @@ type (
 		Select(ctx context.Context, dst any, sql string, args ...any) error
 		Get(ctx context.Context, dst any, sql string, args ...any) error
 		Exec(ctx context.Context, sql string, args ...any) (int64, error)
+		Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+		SendBatch(ctx context.Context, b *conn.Batch) error
+		CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error)
 		Tx(ctx context.Context, f func(n conn.Querier) error, opts ...conn.TxOption) error
+		ScanAPI() *pgxscan.API
 		Primary() conn.Querier
 		Replica() conn.Querier
 		Ping(context.Context) error
 		Close() error
 	}
 
-	ConnPicker func(db Conn, sql string) conn.Querier
+	// ConnPicker picks the physical database for Select and Get.
+	ConnPicker func(ctx context.Context, db Conn, sql string) conn.Querier
 )
 
 var _ Conn = &Cluster{}
 
-type pdb struct {
-	pool    *pgxpool.Pool
-	querier conn.Querier
-}
+const defaultHealthCheckThreshold = 3
 
 type Cluster struct {
-	picker  ConnPicker
-	scanAPI *pgxscan.API
-	pdbs    []*pdb
-	count   uint64
+	picker   ConnPicker
+	balancer Balancer
+	scanAPI  *pgxscan.API
+	count    uint64
+	maxLag   time.Duration
+	lagCheck time.Duration
+	retry    RetryPolicy
+
+	interceptors []conn.Interceptor
+
+	hedgeDelay time.Duration
+
+	// mu guards topology: pdbs are replaced, never modified in place,
+	// so a slice obtained under the lock stays valid after it is released.
+	mu         sync.RWMutex
+	pdbs       []*pdb
+	primaryIdx int
+	observers  []Observer
+	rediscover chan struct{}
+
+	causalTimeout time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // Open concurrently opens each underlying physical db.
 // DSN must be valid according to https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING
-// first being used as the primary and the rest as replica.
+// first being used as the primary and the rest as replica, unless WithPrimaryDiscovery detects otherwise.
 func Open(dsn []string, opts ...Option) (*Cluster, error) {
 	configs := make([]*pgxpool.Config, len(dsn))
 	for i := range dsn {
@@ -59,11 +84,13 @@ func Open(dsn []string, opts ...Option) (*Cluster, error) {
 }
 
 // NewFromConfigs concurrently opens each underlying physical db.
-// first being used as the primary and the rest as replica.
+// first being used as the primary and the rest as replica, unless WithPrimaryDiscovery detects otherwise.
 func NewFromConfigs(config []*pgxpool.Config, opts ...Option) (*Cluster, error) {
 	clusterOpts := &Options{
-		Picker:  defaultConnPicker,
-		ScanAPI: pgxscan.DefaultAPI,
+		Picker:               defaultConnPicker,
+		ScanAPI:              pgxscan.DefaultAPI,
+		HealthCheckThreshold: defaultHealthCheckThreshold,
+		LagCheckInterval:     defaultLagCheckInterval,
 	}
 
 	for _, o := range opts {
@@ -71,9 +98,19 @@ func NewFromConfigs(config []*pgxpool.Config, opts ...Option) (*Cluster, error)
 	}
 
 	db := &Cluster{
-		pdbs:    make([]*pdb, len(config)),
-		picker:  clusterOpts.Picker,
-		scanAPI: clusterOpts.ScanAPI,
+		pdbs:     make([]*pdb, len(config)),
+		picker:   clusterOpts.Picker,
+		balancer: clusterOpts.Balancer,
+		scanAPI:  clusterOpts.ScanAPI,
+		maxLag:   clusterOpts.MaxReplicationLag,
+		lagCheck: clusterOpts.LagCheckInterval,
+		retry:    clusterOpts.ReadRetry,
+
+		interceptors: clusterOpts.Interceptors,
+
+		hedgeDelay: clusterOpts.HedgeDelay,
+
+		causalTimeout: clusterOpts.CausalReadTimeout,
 	}
 
 	err := scatter(len(db.pdbs), func(i int) error {
@@ -82,23 +119,74 @@ func NewFromConfigs(config []*pgxpool.Config, opts ...Option) (*Cluster, error)
 			return fmt.Errorf("failed to create connection pool %d: %w", i, err)
 		}
 
-		db.pdbs[i] = &pdb{
-			pool:    c,
-			querier: conn.WrapConn(c, db.ScanAPI()),
+		weight := defaultWeight
+		if i < len(clusterOpts.Weights) {
+			weight = clusterOpts.Weights[i]
 		}
+
+		db.pdbs[i] = newPDB(db, c, weight)
 		return nil
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize cluster connections: %w", err)
 	}
 
+	if clusterOpts.DiscoveryInterval > 0 {
+		// keep the first db as the primary if roles can't be detected yet.
+		_ = db.discoverPrimary(context.Background(), clusterOpts.DiscoveryInterval)
+	}
+
+	if clusterOpts.MaxReplicationLag > 0 {
+		// replicas are not used for reads until their lag is known.
+		db.checkLag(context.Background(), clusterOpts.LagCheckInterval)
+	}
+
+	db.start(clusterOpts)
+
 	return db, nil
 }
 
-// Close closes all physical databases concurrently, releasing any open resources.
+// start runs background workers enabled by options.
+func (conn *Cluster) start(opts *Options) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn.cancel = cancel
+
+	if opts.HealthCheckInterval > 0 {
+		conn.wg.Add(1)
+		go func() {
+			defer conn.wg.Done()
+			conn.healthCheck(ctx, opts.HealthCheckInterval, opts.HealthCheckThreshold)
+		}()
+	}
+
+	if opts.MaxReplicationLag > 0 {
+		conn.wg.Add(1)
+		go func() {
+			defer conn.wg.Done()
+			conn.lagMonitor(ctx, opts.LagCheckInterval)
+		}()
+	}
+
+	if opts.DiscoveryInterval > 0 {
+		conn.rediscover = make(chan struct{}, 1)
+		conn.wg.Add(1)
+		go func() {
+			defer conn.wg.Done()
+			conn.primaryDiscovery(ctx, opts.DiscoveryInterval)
+		}()
+	}
+}
+
+// Close stops background workers and closes all physical databases concurrently, releasing any open resources.
 func (conn *Cluster) Close() error {
-	return scatter(len(conn.pdbs), func(i int) error {
-		conn.pdbs[i].pool.Close()
+	if conn.cancel != nil {
+		conn.cancel()
+	}
+	conn.wg.Wait()
+
+	pdbs, _ := conn.nodes()
+	return scatter(len(pdbs), func(i int) error {
+		pdbs[i].pool.Close()
 		return nil
 	})
 }
@@ -106,44 +194,224 @@ func (conn *Cluster) Close() error {
 // Ping verifies if a connection to each physical database is still alive,
 // establishing a connection if necessary.
 func (conn *Cluster) Ping(ctx context.Context) error {
-	return scatter(len(conn.pdbs), func(i int) error {
-		return conn.pdbs[i].pool.Ping(ctx)
+	pdbs, _ := conn.nodes()
+	return scatter(len(pdbs), func(i int) error {
+		return pdbs[i].pool.Ping(ctx)
 	})
 }
 
 // Select multiple records.
 // Select uses a replica by default.
+// Routing hints in ctx take precedence over ConnPicker, see WithPrimary, WithReplica and WithNode.
+// If ctx carries an LSN token only replicas that caught up with it are used.
 // See Querier.Select for details.
 func (conn *Cluster) Select(ctx context.Context, dst any, sql string, args ...any) error {
-	return conn.picker(conn, sql).Select(ctx, dst, sql, args...)
+	return conn.read(ctx, sql, dst, selectFunc(sql, args))
 }
 
 // Get retrieve one row.
 // Get uses a replica by default.
+// Routing hints in ctx take precedence over ConnPicker, see WithPrimary, WithReplica and WithNode.
+// If ctx carries an LSN token only replicas that caught up with it are used.
 // See Querier.Get for details.
 func (conn *Cluster) Get(ctx context.Context, dst any, sql string, args ...any) error {
-	return conn.picker(conn, sql).Get(ctx, dst, sql, args...)
+	return conn.read(ctx, sql, dst, getFunc(sql, args))
+}
+
+// Query executes a query and returns its rows, the caller must close them.
+// Query is routed like Select, but it is neither retried nor hedged,
+// see conn.Iterate for scanning rows one by one.
+func (conn *Cluster) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q, err := conn.route(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Query(ctx, sql, args...)
 }
 
 // Exec executes a query on primary without returning any rows and return affected rows.
+// If ctx carries an LSN token it is advanced past the write.
 func (conn *Cluster) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
-	return conn.Primary().Exec(ctx, sql, args...)
+	rows, err := conn.Primary().Exec(ctx, sql, args...)
+	if err != nil {
+		conn.checkReadOnly(err)
+		return 0, err
+	}
+
+	conn.trackWrite(ctx)
+	return rows, nil
+}
+
+// SendBatch sends statements of b in one round trip.
+// A batch of Get and Select statements the ConnPicker would all send to a replica is routed like a read-only transaction,
+// any other batch goes to the primary. Batches are neither retried nor hedged.
+// If ctx carries an LSN token it is advanced past a batch sent to the primary.
+func (conn *Cluster) SendBatch(ctx context.Context, b *conn.Batch) error {
+	if b.Len() == 0 {
+		return nil
+	}
+
+	if conn.batchReadOnly(ctx, b) {
+		q, err := conn.routeReadOnly(ctx)
+		if err != nil {
+			return err
+		}
+
+		return q.SendBatch(ctx, b)
+	}
+
+	if err := conn.Primary().SendBatch(ctx, b); err != nil {
+		conn.checkReadOnly(err)
+		return err
+	}
+
+	conn.trackWrite(ctx)
+	return nil
+}
+
+// batchReadOnly reports whether b has only reads the ConnPicker sends to a replica.
+// The picker sees a pickerView, so classifying the items does not advance the balancer.
+func (conn *Cluster) batchReadOnly(ctx context.Context, b *conn.Batch) bool {
+	if !b.ReadOnly() {
+		return false
+	}
+
+	view := &pickerView{
+		Cluster:     conn,
+		primaryMark: &replicaQuerier{cluster: conn, db: conn.primary()},
+		replicaMark: &replicaQuerier{cluster: conn, db: conn.primary()},
+	}
+	for _, item := range b.Items() {
+		if conn.picker(ctx, view, item.SQL) != view.replicaMark {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pickerView is the cluster as seen by the ConnPicker when only its decision is needed:
+// Primary and Replica return marks instead of picking a node.
+type pickerView struct {
+	*Cluster
+	primaryMark conn.Querier
+	replicaMark conn.Querier
+}
+
+func (v *pickerView) Primary() conn.Querier {
+	return v.primaryMark
+}
+
+func (v *pickerView) Replica() conn.Querier {
+	return v.replicaMark
+}
+
+// CopyFrom inserts rows into table on primary with the COPY protocol, see Querier.CopyFrom for details.
+// If ctx carries an LSN token it is advanced past the copy.
+func (conn *Cluster) CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error) {
+	copied, err := conn.Primary().CopyFrom(ctx, table, rows)
+	if err != nil {
+		conn.checkReadOnly(err)
+		return 0, err
+	}
+
+	conn.trackWrite(ctx)
+	return copied, nil
 }
 
 // Tx starts a transaction on primary and calls f.
+// If ctx carries an LSN token it is advanced past the commit. Inside a transaction from ctx
+// only a conn.PropagationRequiresNew transaction commits on its own and advances it.
+// A read-only transaction, see conn.ReadOnly, runs on a replica chosen like for Select
+// unless ctx already carries a transaction. Serializable transactions, including deferrable ones,
+// run on the primary, hot standbys don't support them.
 // See Querier.Tx for details.
 func (conn *Cluster) Tx(ctx context.Context, f func(n conn.Querier) error, opts ...conn.TxOption) error {
-	return conn.Primary().Tx(ctx, f, opts...)
+	if replicaTx(opts) && !inTx(ctx) {
+		q, err := conn.routeReadOnly(ctx)
+		if err != nil {
+			return err
+		}
+
+		return q.Tx(ctx, f, opts...)
+	}
+
+	if err := conn.Primary().Tx(ctx, f, opts...); err != nil {
+		conn.checkReadOnly(err)
+		return err
+	}
+
+	if independentTx(opts) {
+		conn.trackCommit(ctx)
+		return nil
+	}
+
+	conn.trackWrite(ctx)
+	return nil
 }
 
 // Primary returns the primary physical database.
 func (conn *Cluster) Primary() conn.Querier {
-	return conn.pdbs[0].querier
+	return conn.primary().querier
 }
 
-// Replica returns one of the replica databases.
+// Replica returns one of the healthy replica databases within the replication lag bound.
+// If there are no such replicas the primary is returned.
+// Routing hints in ctx of the querier calls take precedence over the selected replica.
 func (conn *Cluster) Replica() conn.Querier {
-	return conn.pdbs[conn.replica(len(conn.pdbs))].querier
+	return &replicaQuerier{cluster: conn, db: conn.pickReplica()}
+}
+
+// pickReplica returns the next read candidate chosen by the balancer, round-robin by default.
+func (conn *Cluster) pickReplica() *pdb {
+	candidates := conn.candidates()
+	if conn.balancer == nil || len(candidates) == 1 {
+		return candidates[conn.replica(len(candidates))]
+	}
+
+	replicas := candidates[1:]
+	nodes := make([]Node, len(replicas))
+	for i := range replicas {
+		nodes[i] = replicas[i]
+	}
+
+	return replicas[conn.balancer.Pick(nodes)]
+}
+
+// candidates returns the primary followed by replicas eligible for reads.
+func (conn *Cluster) candidates() []*pdb {
+	pdbs, primary := conn.nodes()
+	candidates := make([]*pdb, 1, len(pdbs))
+	candidates[0] = primary
+	for _, db := range pdbs {
+		if db != primary && db.healthy() && !db.draining.Load() && (conn.maxLag == 0 || db.withinLag(conn.maxLag)) {
+			candidates = append(candidates, db)
+		}
+	}
+
+	return candidates
+}
+
+// nodes returns the physical databases and the primary.
+// The returned slice must not be modified.
+func (conn *Cluster) nodes() ([]*pdb, *pdb) {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	return conn.pdbs, conn.pdbs[conn.primaryIdx]
+}
+
+func (conn *Cluster) primary() *pdb {
+	_, primary := conn.nodes()
+	return primary
+}
+
+func (conn *Cluster) primaryIndex() int {
+	conn.mu.RLock()
+	defer conn.mu.RUnlock()
+
+	return conn.primaryIdx
 }
 
 func (conn *Cluster) replica(n int) int {
@@ -153,6 +421,12 @@ func (conn *Cluster) replica(n int) int {
 	return int(1 + (atomic.AddUint64(&conn.count, 1) % uint64(n-1))) //nolint: gosec // It's not possible to overflow here.
 }
 
+// ScanAPI returns the scan API used to scan rows.
 func (conn *Cluster) ScanAPI() *pgxscan.API {
 	return conn.scanAPI
 }
+
+// Interceptors returns interceptors installed with WithInterceptors.
+func (conn *Cluster) Interceptors() []conn.Interceptor {
+	return conn.interceptors
+}
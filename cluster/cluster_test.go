@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
@@ -10,6 +11,7 @@ import (
 	"testing/quick"
 	"time"
 
+	"github.com/MrEhbr/pgxext/v2/conn"
 	"github.com/hashicorp/go-multierror"
 	"github.com/jackc/pgmock"
 	"github.com/jackc/pgproto3/v2"
@@ -157,3 +159,26 @@ func Test_replica(t *testing.T) {
 	}, nil)
 	is.NoErr(err)
 }
+
+func TestInterceptors(t *testing.T) {
+	is := is.New(t)
+
+	var calls []string
+	errBlocked := errors.New("blocked")
+	db, err := Open(
+		[]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"},
+		WithInterceptors(func(_ context.Context, call *conn.Call, _ conn.Handler) error {
+			calls = append(calls, string(call.Method)+" "+call.SQL)
+			return errBlocked
+		}),
+	)
+	is.NoErr(err)
+	defer db.Close()
+
+	_, err = db.Exec(t.Context(), "DELETE FROM users")
+	is.Equal(err, errBlocked)
+
+	var n int
+	is.Equal(db.Get(t.Context(), &n, "SELECT 1"), errBlocked)
+	is.Equal(calls, []string{"Exec DELETE FROM users", "Get SELECT 1"}) // calls on nodes pass through the chain
+}
@@ -0,0 +1,189 @@
+// Package clusterprom exports cluster.Cluster metrics to Prometheus.
+package clusterprom
+
+import (
+	"context"
+
+	"github.com/MrEhbr/pgxext/v2/cluster"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultNamespace = "pgxext"
+
+var _ prometheus.Collector = &Collector{}
+
+// Collector is a prometheus.Collector of cluster metrics.
+// Pool gauges, health and replica lag are read from Cluster.Stats on each scrape,
+// query latency and transaction outcomes are recorded as calls finish.
+type Collector struct {
+	cluster *cluster.Cluster
+
+	duration     *prometheus.HistogramVec
+	errors       *prometheus.CounterVec
+	transactions *prometheus.CounterVec
+
+	up                   *prometheus.Desc
+	lag                  *prometheus.Desc
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+}
+
+// Options for collector.
+type Options struct {
+	// Namespace of metric names, pgxext by default.
+	Namespace string
+	// ConstLabels are added to every metric.
+	ConstLabels prometheus.Labels
+	// Buckets of the query duration histogram, prometheus.DefBuckets by default.
+	Buckets []float64
+}
+
+// Option func.
+type Option func(*Options)
+
+// WithNamespace sets namespace of metric names.
+func WithNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.Namespace = namespace
+	}
+}
+
+// WithConstLabels sets labels added to every metric, e.g. to tell clusters apart.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(o *Options) {
+		o.ConstLabels = labels
+	}
+}
+
+// WithBuckets sets buckets of the query duration histogram in seconds.
+func WithBuckets(buckets []float64) Option {
+	return func(o *Options) {
+		if len(buckets) > 0 {
+			o.Buckets = buckets
+		}
+	}
+}
+
+// NewCollector returns a collector of c metrics and registers it as an observer of c.
+// The collector must be registered with a prometheus.Registerer to be scraped.
+func NewCollector(c *cluster.Cluster, opts ...Option) *Collector {
+	options := &Options{
+		Namespace: defaultNamespace,
+		Buckets:   prometheus.DefBuckets,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	nodeLabels := []string{"node", "role"}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(options.Namespace, "", name), help, nodeLabels, options.ConstLabels)
+	}
+
+	collector := &Collector{
+		cluster: c,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   options.Namespace,
+			Name:        "query_duration_seconds",
+			Help:        "Duration of Select, Get, Exec, Query, Batch, Copy and Tx calls on a node.",
+			ConstLabels: options.ConstLabels,
+			Buckets:     options.Buckets,
+		}, []string{"node", "role", "method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   options.Namespace,
+			Name:        "query_errors_total",
+			Help:        "Number of failed Select, Get, Exec, Query, Batch, Copy and Tx calls on a node.",
+			ConstLabels: options.ConstLabels,
+		}, []string{"node", "role", "method"}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   options.Namespace,
+			Name:        "transactions_total",
+			Help:        "Number of transactions on a node by outcome: commit, rollback or error.",
+			ConstLabels: options.ConstLabels,
+		}, []string{"node", "role", "outcome"}),
+
+		up:                   desc("node_up", "Whether the node takes part in routing."),
+		lag:                  desc("replication_lag_seconds", "Last observed replication lag of the replica."),
+		acquiredConns:        desc("pool_acquired_conns", "Number of connections currently in use."),
+		idleConns:            desc("pool_idle_conns", "Number of idle connections."),
+		constructingConns:    desc("pool_constructing_conns", "Number of connections being established."),
+		totalConns:           desc("pool_total_conns", "Number of acquired, idle and constructing connections."),
+		maxConns:             desc("pool_max_conns", "Maximum size of the pool."),
+		acquireCount:         desc("pool_acquires_total", "Number of successful connection acquires."),
+		acquireDuration:      desc("pool_acquire_duration_seconds_total", "Total time spent by successful connection acquires."),
+		emptyAcquireCount:    desc("pool_empty_acquires_total", "Number of successful acquires that waited for a connection."),
+		canceledAcquireCount: desc("pool_canceled_acquires_total", "Number of connection acquires canceled by a context."),
+	}
+
+	c.AddObserver(collector)
+
+	return collector
+}
+
+// Observe records a finished call, it implements cluster.Observer.
+func (c *Collector) Observe(_ context.Context, e cluster.Event) {
+	c.duration.WithLabelValues(e.Node, string(e.Role), string(e.Method)).Observe(e.Duration.Seconds())
+	if e.Err != nil {
+		c.errors.WithLabelValues(e.Node, string(e.Role), string(e.Method)).Inc()
+	}
+	if e.Method == cluster.MethodTx {
+		c.transactions.WithLabelValues(e.Node, string(e.Role), string(e.Outcome)).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.duration.Describe(ch)
+	c.errors.Describe(ch)
+	c.transactions.Describe(ch)
+
+	for _, d := range []*prometheus.Desc{
+		c.up, c.lag, c.acquiredConns, c.idleConns, c.constructingConns, c.totalConns, c.maxConns,
+		c.acquireCount, c.acquireDuration, c.emptyAcquireCount, c.canceledAcquireCount,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.duration.Collect(ch)
+	c.errors.Collect(ch)
+	c.transactions.Collect(ch)
+
+	for _, node := range c.cluster.Stats().Nodes {
+		labels := []string{node.Name, string(node.Role)}
+		gauge := func(d *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, labels...)
+		}
+		counter := func(d *prometheus.Desc, v float64) {
+			ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v, labels...)
+		}
+
+		up := 0.0
+		if node.Healthy {
+			up = 1
+		}
+		gauge(c.up, up)
+		if node.Role == cluster.RoleReplica && node.Lag >= 0 {
+			gauge(c.lag, node.Lag.Seconds())
+		}
+
+		gauge(c.acquiredConns, float64(node.Pool.AcquiredConns))
+		gauge(c.idleConns, float64(node.Pool.IdleConns))
+		gauge(c.constructingConns, float64(node.Pool.ConstructingConns))
+		gauge(c.totalConns, float64(node.Pool.TotalConns))
+		gauge(c.maxConns, float64(node.Pool.MaxConns))
+		counter(c.acquireCount, float64(node.Pool.AcquireCount))
+		counter(c.acquireDuration, node.Pool.AcquireDuration.Seconds())
+		counter(c.emptyAcquireCount, float64(node.Pool.EmptyAcquireCount))
+		counter(c.canceledAcquireCount, float64(node.Pool.CanceledAcquireCount))
+	}
+}
@@ -0,0 +1,71 @@
+package clusterprom
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/cluster"
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/matryer/is"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	is := is.New(t)
+
+	db, err := cluster.Open([]string{
+		"host=127.0.0.1 port=1 pool_max_conns=2 connect_timeout=1",
+		"host=127.0.0.1 port=2 pool_max_conns=3 connect_timeout=1",
+	})
+	is.NoErr(err)
+	defer db.Close()
+
+	collector := NewCollector(db, WithConstLabels(prometheus.Labels{"cluster": "main"}))
+	registry := prometheus.NewPedanticRegistry()
+	is.NoErr(registry.Register(collector))
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	_, err = db.Exec(ctx, "DELETE FROM users")
+	is.True(err != nil) // primary is unreachable
+	is.True(db.Tx(ctx, func(conn.Querier) error { return nil }) != nil)
+
+	collector.Observe(ctx, cluster.Event{
+		Node:     "127.0.0.1:2",
+		Role:     cluster.RoleReplica,
+		Method:   cluster.MethodSelect,
+		Duration: 3 * time.Millisecond,
+	})
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP pgxext_query_errors_total Number of failed Select, Get, Exec, Query, Batch, Copy and Tx calls on a node.
+# TYPE pgxext_query_errors_total counter
+pgxext_query_errors_total{cluster="main",method="Exec",node="127.0.0.1:1",role="primary"} 1
+pgxext_query_errors_total{cluster="main",method="Tx",node="127.0.0.1:1",role="primary"} 1
+# HELP pgxext_transactions_total Number of transactions on a node by outcome: commit, rollback or error.
+# TYPE pgxext_transactions_total counter
+pgxext_transactions_total{cluster="main",node="127.0.0.1:1",outcome="error",role="primary"} 1
+# HELP pgxext_node_up Whether the node takes part in routing.
+# TYPE pgxext_node_up gauge
+pgxext_node_up{cluster="main",node="127.0.0.1:1",role="primary"} 1
+pgxext_node_up{cluster="main",node="127.0.0.1:2",role="replica"} 1
+# HELP pgxext_pool_max_conns Maximum size of the pool.
+# TYPE pgxext_pool_max_conns gauge
+pgxext_pool_max_conns{cluster="main",node="127.0.0.1:1",role="primary"} 2
+pgxext_pool_max_conns{cluster="main",node="127.0.0.1:2",role="replica"} 3
+`),
+		"pgxext_query_errors_total",
+		"pgxext_transactions_total",
+		"pgxext_node_up",
+		"pgxext_replication_lag_seconds", // unknown without lag monitoring
+		"pgxext_pool_max_conns",
+	)
+	is.NoErr(err)
+
+	// one histogram per node, role and method
+	is.Equal(testutil.CollectAndCount(collector, "pgxext_query_duration_seconds"), 3)
+}
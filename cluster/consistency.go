@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+)
+
+const (
+	currentLSNQuery = "SELECT pg_current_wal_lsn()::text"
+	replayLSNQuery  = "SELECT COALESCE(pg_last_wal_replay_lsn(), '0/0')::text"
+
+	// causalPollInterval is the delay between replay position checks while waiting for replicas to catch up.
+	causalPollInterval = 10 * time.Millisecond
+)
+
+type lsnKeyType uint8
+
+const (
+	lsnKey lsnKeyType = 0
+)
+
+// lsnToken is a position in the write-ahead log the reads in the context must observe.
+type lsnToken struct {
+	lsn atomic.Uint64
+}
+
+// NewLSNContext returns a new context carrying an LSN token starting at lsn.
+// Writes done through Cluster.Exec and Cluster.Tx with the returned context advance the token
+// to the primary WAL position, Cluster.Get and Cluster.Select route only to replicas
+// that replayed the token position.
+// Pass zero lsn to start a new session, or a value from LSNFromContext to continue one.
+func NewLSNContext(ctx context.Context, lsn LSN) context.Context {
+	token := &lsnToken{}
+	token.lsn.Store(uint64(lsn))
+
+	return context.WithValue(ctx, lsnKey, token)
+}
+
+// LSNFromContext extracts the LSN token if present.
+func LSNFromContext(ctx context.Context) (LSN, bool) {
+	token, ok := ctx.Value(lsnKey).(*lsnToken)
+	if !ok {
+		return 0, false
+	}
+
+	return LSN(token.lsn.Load()), true
+}
+
+// advance moves the token forward to lsn, the token never goes backwards.
+func (t *lsnToken) advance(lsn LSN) {
+	for {
+		current := t.lsn.Load()
+		if current >= uint64(lsn) || t.lsn.CompareAndSwap(current, uint64(lsn)) {
+			return
+		}
+	}
+}
+
+// trackWrite advances the LSN token in ctx to the current primary WAL position.
+// If the position can't be fetched the token is moved past any position, pinning reads to the primary.
+// Writes inside a transaction from context are not tracked, they are not visible to anybody before commit.
+func (conn *Cluster) trackWrite(ctx context.Context) {
+	if inTx(ctx) {
+		return
+	}
+
+	conn.trackCommit(ctx)
+}
+
+// trackCommit advances the LSN token in ctx like trackWrite, but also inside a transaction from context,
+// it is used for independent transactions committed before the one in progress.
+func (conn *Cluster) trackCommit(ctx context.Context) {
+	token, ok := ctx.Value(lsnKey).(*lsnToken)
+	if !ok {
+		return
+	}
+
+	current, err := conn.primary().queryCurrentLSN(ctx)
+	if err != nil {
+		current = math.MaxUint64
+	}
+
+	token.advance(current)
+}
+
+// causalReplica returns q if it already replayed the LSN token in ctx.
+// Otherwise it waits up to the causal read timeout for any read candidate to catch up
+// and falls back to the primary.
+func (conn *Cluster) causalReplica(ctx context.Context, q conn.Querier) conn.Querier {
+	lsn, ok := LSNFromContext(ctx)
+	if !ok || lsn == 0 {
+		return q
+	}
+
+	db := conn.nodeOf(q)
+	if db == nil || db == conn.primary() || db.replayed(lsn) {
+		return q
+	}
+
+	deadline := time.Now().Add(conn.causalTimeout)
+	for {
+		candidates := conn.candidates()[1:]
+		_ = scatter(len(candidates), func(i int) error {
+			_ = candidates[i].queryReplayLSN(ctx)
+			return nil
+		})
+
+		for _, candidate := range candidates {
+			if candidate.replayed(lsn) {
+				return candidate.querier
+			}
+		}
+
+		if ctx.Err() != nil || time.Now().Add(causalPollInterval).After(deadline) {
+			return conn.Primary()
+		}
+
+		time.Sleep(causalPollInterval)
+	}
+}
+
+// nodeOf returns the physical database serving q or nil if q is not a cluster node.
+func (conn *Cluster) nodeOf(q conn.Querier) *pdb {
+	if replica, ok := q.(*replicaQuerier); ok {
+		return replica.db
+	}
+
+	pdbs, _ := conn.nodes()
+	for _, db := range pdbs {
+		if db.querier == q {
+			return db
+		}
+	}
+
+	return nil
+}
+
+// queryCurrentLSN fetches the current WAL write position of the node.
+func (db *pdb) queryCurrentLSN(ctx context.Context) (LSN, error) {
+	var lsn string
+	if err := db.pool.QueryRow(ctx, currentLSNQuery).Scan(&lsn); err != nil {
+		return 0, fmt.Errorf("query current wal lsn: %w", err)
+	}
+
+	return ParseLSN(lsn)
+}
+
+// queryReplayLSN fetches and stores the last replayed LSN of the node.
+func (db *pdb) queryReplayLSN(ctx context.Context) error {
+	var lsn string
+	if err := db.pool.QueryRow(ctx, replayLSNQuery).Scan(&lsn); err != nil {
+		return fmt.Errorf("query replay lsn: %w", err)
+	}
+
+	replayLSN, err := ParseLSN(lsn)
+	if err != nil {
+		return err
+	}
+
+	db.replayLSN.Store(uint64(replayLSN))
+	return nil
+}
+
+// replayed reports whether the node is known to have replayed lsn.
+func (db *pdb) replayed(lsn LSN) bool {
+	return LSN(db.replayLSN.Load()) >= lsn
+}
+
+// caughtUp reports whether db replayed the LSN token in ctx, if any.
+func caughtUp(ctx context.Context, db *pdb) bool {
+	lsn, ok := LSNFromContext(ctx)
+	return !ok || db.replayed(lsn)
+}
+
+// inTx reports whether ctx carries a transaction.
+func inTx(ctx context.Context) bool {
+	_, ok := conn.TxFromContext(ctx)
+	return ok
+}
@@ -0,0 +1,94 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matryer/is"
+)
+
+func TestLSNContext(t *testing.T) {
+	is := is.New(t)
+
+	_, ok := LSNFromContext(t.Context())
+	is.True(!ok) // no token by default
+
+	ctx := NewLSNContext(t.Context(), 10)
+	lsn, ok := LSNFromContext(ctx)
+	is.True(ok)
+	is.Equal(lsn, LSN(10))
+
+	token, _ := ctx.Value(lsnKey).(*lsnToken)
+	token.advance(20)
+	token.advance(15)
+	lsn, _ = LSNFromContext(ctx)
+	is.Equal(lsn, LSN(20)) // token never goes backwards
+}
+
+func TestTrackWrite(t *testing.T) {
+	is := is.New(t)
+
+	db, err := Open([]string{"host=127.0.0.1 port=1"})
+	is.NoErr(err)
+	defer db.Close()
+
+	ctx := NewLSNContext(conn.NewTxContext(t.Context(), &pgxpool.Tx{}), 10)
+	db.trackWrite(ctx)
+	lsn, _ := LSNFromContext(ctx)
+	is.Equal(lsn, LSN(10)) // writes of the transaction in progress are not committed yet
+
+	db.trackCommit(ctx)
+	lsn, _ = LSNFromContext(ctx)
+	is.Equal(lsn, LSN(math.MaxUint64)) // independent commit pins reads to the primary when its position is unknown
+
+	is.True(independentTx([]conn.TxOption{conn.WithPropagation(conn.PropagationRequiresNew)}))
+	is.True(!independentTx([]conn.TxOption{conn.WithPropagation(conn.PropagationRequired)}))
+}
+
+func TestCausalReplica(t *testing.T) {
+	t.Run("caught up replica is kept", func(t *testing.T) {
+		is := is.New(t)
+
+		primary := &pdb{querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+		replica := &pdb{querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+		replica.replayLSN.Store(100)
+		db := &Cluster{pdbs: []*pdb{primary, replica}}
+
+		is.Equal(db.causalReplica(t.Context(), replica.querier), replica.querier)                     // no token
+		is.Equal(db.causalReplica(NewLSNContext(t.Context(), 100), replica.querier), replica.querier) // replayed token
+		is.Equal(db.causalReplica(NewLSNContext(t.Context(), 200), primary.querier), primary.querier) // primary is always up to date
+	})
+
+	t.Run("falls back to primary", func(t *testing.T) {
+		is := is.New(t)
+
+		cfg, err := pgxpool.ParseConfig("host=127.0.0.1 port=1")
+		is.NoErr(err)
+		db, err := NewFromConfigs([]*pgxpool.Config{cfg, cfg.Copy()})
+		is.NoErr(err)
+		defer db.Close()
+
+		ctx := NewLSNContext(t.Context(), 100)
+		is.Equal(db.causalReplica(ctx, db.pdbs[1].querier), db.Primary()) // replica can't confirm replay position
+	})
+
+	t.Run("replica hint honors token", func(t *testing.T) {
+		is := is.New(t)
+
+		db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"})
+		is.NoErr(err)
+		defer db.Close()
+
+		ctx := WithReplica(NewLSNContext(t.Context(), 100))
+		q, err := db.route(ctx, "SELECT 1")
+		is.NoErr(err)
+		is.Equal(q, db.Primary()) // replica behind the token is not forced
+
+		target, err := db.Replica().(*replicaQuerier).target(ctx)
+		is.NoErr(err)
+		is.Equal(target, db.primary())
+	})
+}
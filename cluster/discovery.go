@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	inRecoveryQuery = "SELECT pg_is_in_recovery()"
+
+	// readOnlyTransactionCode is SQLSTATE of read_only_sql_transaction error
+	// returned by a node that is no longer the primary.
+	readOnlyTransactionCode = "25006"
+)
+
+// ErrNoPrimary is returned when none of physical databases is the primary.
+var ErrNoPrimary = errors.New("no primary found")
+
+// primaryDiscovery detects node roles each interval or on request until ctx is canceled.
+func (conn *Cluster) primaryDiscovery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-conn.rediscover:
+		}
+
+		_ = conn.discoverPrimary(ctx, interval)
+	}
+}
+
+// discoverPrimary asks each physical database whether it is in recovery
+// and makes the one that is not the primary.
+// The current primary is kept while it is still writable.
+func (conn *Cluster) discoverPrimary(ctx context.Context, timeout time.Duration) error {
+	pdbs, primary := conn.nodes()
+	writable := make([]bool, len(pdbs))
+	_ = scatter(len(pdbs), func(i int) error {
+		queryCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		var inRecovery bool
+		if err := pdbs[i].pool.QueryRow(queryCtx, inRecoveryQuery).Scan(&inRecovery); err != nil {
+			return fmt.Errorf("query recovery status: %w", err)
+		}
+
+		writable[i] = !inRecovery
+		return nil
+	})
+
+	for i, db := range pdbs {
+		if db == primary && writable[i] {
+			return nil
+		}
+	}
+
+	for i := range writable {
+		if writable[i] && conn.setPrimary(pdbs[i]) {
+			return nil
+		}
+	}
+
+	return ErrNoPrimary
+}
+
+// requestDiscovery asks the discovery worker to detect node roles as soon as possible.
+// It does nothing if primary discovery is disabled.
+func (conn *Cluster) requestDiscovery() {
+	select {
+	case conn.rediscover <- struct{}{}:
+	default:
+	}
+}
+
+// checkReadOnly requests primary discovery if err says the primary became read-only.
+func (conn *Cluster) checkReadOnly(err error) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == readOnlyTransactionCode {
+		conn.requestDiscovery()
+	}
+}
@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgmock"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/matryer/is"
+)
+
+func inRecoverySteps(inRecovery bool) []pgmock.Step {
+	value := "f"
+	if inRecovery {
+		value = "t"
+	}
+
+	return []pgmock.Step{
+		pgmock.ExpectMessage(&pgproto3.Query{String: inRecoveryQuery}),
+		pgmock.SendMessage(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+			{Name: []byte("pg_is_in_recovery"), DataTypeOID: 16, DataTypeSize: 1, TypeModifier: -1},
+		}}),
+		pgmock.SendMessage(&pgproto3.DataRow{Values: [][]byte{[]byte(value)}}),
+		pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")}),
+		pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: 'I'}),
+		pgmock.ExpectMessage(&pgproto3.Terminate{}),
+	}
+}
+
+func TestPrimaryDiscovery(t *testing.T) {
+	t.Run("primary detected on open", func(t *testing.T) {
+		is := is.New(t)
+
+		const simple = " default_query_exec_mode=simple_protocol"
+		one := testDatabase(t, inRecoverySteps(true)...) + simple
+		two := testDatabase(t, inRecoverySteps(false)...) + simple
+		three := testDatabase(t, inRecoverySteps(true)...) + simple
+
+		db, err := Open([]string{one, two, three}, WithPrimaryDiscovery(time.Hour))
+		is.NoErr(err)
+		defer db.Close()
+
+		is.Equal(db.primaryIndex(), 1) // the only writable node is the primary
+		is.Equal(db.Primary(), db.pdbs[1].querier)
+		is.True(db.Health()[1].Primary)
+		for range 10 {
+			is.True(db.nodeOf(db.Replica()) != db.primary()) // primary is not used for reads
+		}
+	})
+
+	t.Run("primary kept when roles are unknown", func(t *testing.T) {
+		is := is.New(t)
+
+		db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"}, WithPrimaryDiscovery(time.Hour))
+		is.NoErr(err)
+		defer db.Close()
+
+		is.Equal(db.primaryIndex(), 0)
+		is.Equal(db.discoverPrimary(t.Context(), time.Second), ErrNoPrimary)
+	})
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	is := is.New(t)
+
+	db := &Cluster{rediscover: make(chan struct{}, 1)}
+
+	db.checkReadOnly(errors.New("boom"))
+	is.Equal(len(db.rediscover), 0) // other errors are ignored
+
+	db.checkReadOnly(&pgconn.PgError{Code: readOnlyTransactionCode})
+	db.checkReadOnly(&pgconn.PgError{Code: readOnlyTransactionCode})
+	is.Equal(len(db.rediscover), 1) // discovery requested once without blocking
+
+	(&Cluster{}).checkReadOnly(&pgconn.PgError{Code: readOnlyTransactionCode}) // discovery disabled
+}
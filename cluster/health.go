@@ -0,0 +1,94 @@
+package cluster
+
+import (
+	"context"
+	"time"
+)
+
+// NodeHealth describes health state of a physical database.
+type NodeHealth struct {
+	// Name of the node in host:port form.
+	Name string
+	// Primary reports whether the node is the primary.
+	Primary bool
+	// Healthy reports whether the node takes part in routing.
+	Healthy bool
+	// Failures is the number of consecutive failed health checks.
+	Failures int
+	// Lag is the last observed replication lag, -1 if unknown.
+	Lag time.Duration
+}
+
+// Health returns health state of each physical database in configuration order.
+func (conn *Cluster) Health() []NodeHealth {
+	pdbs, primary := conn.nodes()
+	health := make([]NodeHealth, len(pdbs))
+	for i, db := range pdbs {
+		lag, ok := db.replicationLag()
+		if !ok {
+			lag = lagUnknown
+		}
+
+		health[i] = NodeHealth{
+			Name:     db.name,
+			Primary:  db == primary,
+			Healthy:  db.healthy(),
+			Failures: int(db.failures.Load()),
+			Lag:      lag,
+		}
+	}
+
+	return health
+}
+
+// healthCheck pings every physical database each interval until ctx is canceled.
+func (conn *Cluster) healthCheck(ctx context.Context, interval time.Duration, threshold int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			conn.checkHealth(ctx, interval, threshold)
+		}
+	}
+}
+
+// checkHealth pings every physical database concurrently and updates its health state.
+func (conn *Cluster) checkHealth(ctx context.Context, timeout time.Duration, threshold int) {
+	pdbs, _ := conn.nodes()
+	_ = scatter(len(pdbs), func(i int) error {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		err := pdbs[i].pool.Ping(pingCtx)
+		if ctx.Err() != nil {
+			// cluster is closing, the result says nothing about the node.
+			return nil
+		}
+
+		pdbs[i].reportHealth(err, threshold)
+		return nil
+	})
+}
+
+func (db *pdb) healthy() bool {
+	return !db.unhealthy.Load()
+}
+
+// reportHealth records the result of a health check.
+// The node is marked unhealthy after threshold consecutive failures
+// and healthy again after the first successful check.
+func (db *pdb) reportHealth(err error, threshold int) {
+	if err == nil {
+		db.failures.Store(0)
+		db.unhealthy.Store(false)
+		return
+	}
+
+	if int(db.failures.Add(1)) >= threshold {
+		db.unhealthy.Store(true)
+	}
+}
@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestReportHealth(t *testing.T) {
+	is := is.New(t)
+
+	db := &pdb{}
+	is.True(db.healthy()) // node is healthy by default
+
+	db.reportHealth(errors.New("boom"), 2)
+	is.True(db.healthy()) // one failure is below threshold
+
+	db.reportHealth(errors.New("boom"), 2)
+	is.True(!db.healthy()) // threshold reached
+	is.Equal(db.failures.Load(), int32(2))
+
+	db.reportHealth(nil, 2)
+	is.True(db.healthy()) // recovered after successful check
+	is.Equal(db.failures.Load(), int32(0))
+}
+
+func TestReplicaSkipsUnhealthy(t *testing.T) {
+	is := is.New(t)
+
+	primary, one, two := &pdb{name: "primary"}, &pdb{name: "one"}, &pdb{name: "two"}
+	db := &Cluster{pdbs: []*pdb{primary, one, two}}
+
+	two.unhealthy.Store(true)
+	for range 10 {
+		is.Equal(db.candidates()[db.replica(len(db.candidates()))], one) // only healthy replica is used
+	}
+
+	one.unhealthy.Store(true)
+	is.Equal(db.candidates(), []*pdb{primary}) // primary is used when there are no healthy replicas
+
+	health := db.Health()
+	is.Equal(len(health), 3)
+	is.True(health[0].Primary && health[0].Healthy)
+	is.True(!health[1].Healthy && !health[2].Healthy)
+}
+
+func TestHealthCheck(t *testing.T) {
+	is := is.New(t)
+
+	db, err := Open(
+		[]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"},
+		WithHealthCheck(10*time.Millisecond, 2),
+	)
+	is.NoErr(err)
+	defer db.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for db.Health()[1].Healthy && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	health := db.Health()
+	is.Equal(health[1].Name, "127.0.0.1:2")
+	is.True(!health[1].Healthy)                     // unreachable replica must be ejected
+	is.True(health[1].Failures >= 2)                // after threshold failures
+	is.Equal(db.nodeOf(db.Replica()), db.primary()) // reads fall back to primary
+}
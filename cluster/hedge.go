@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+)
+
+type hedgeKeyType uint8
+
+const (
+	hedgeKey hedgeKeyType = 0
+)
+
+// readFunc reads into dst using q.
+type readFunc func(ctx context.Context, q conn.Querier, dst any) error
+
+// WithHedgeDelay returns a new context making Select and Get issue the same read to another replica
+// if the first one has not answered within delay. Zero delay disables hedging for the call chain.
+// It overrides WithHedging option.
+func WithHedgeDelay(ctx context.Context, delay time.Duration) context.Context {
+	return context.WithValue(ctx, hedgeKey, delay)
+}
+
+// hedge runs fn on q and, if it has not finished within the hedge delay, on another replica.
+// The first successful result is stored into dst and the other read is canceled.
+// Each read scans into its own copy of dst, so dst is written only once.
+// Reads sent to the primary and reads in a transaction from ctx are not hedged,
+// the transaction connection can't run two queries at once.
+func (conn *Cluster) hedge(ctx context.Context, q conn.Querier, dst any, fn readFunc) error {
+	delay := conn.hedgeDelay
+	if d, ok := ctx.Value(hedgeKey).(time.Duration); ok {
+		delay = d
+	}
+
+	dstValue := reflect.ValueOf(dst)
+	if delay <= 0 || pinned(ctx) || inTx(ctx) || dstValue.Kind() != reflect.Pointer || dstValue.IsNil() {
+		return fn(ctx, q, dst)
+	}
+
+	second := conn.hedgeTarget(ctx, q)
+	if second == nil {
+		return fn(ctx, q, dst)
+	}
+
+	return hedgedRead(ctx, delay, q, second.querier, dstValue, fn)
+}
+
+// hedgedRead runs fn on first and, if it has not finished within delay, on second.
+func hedgedRead(ctx context.Context, delay time.Duration, first, second conn.Querier, dstValue reflect.Value, fn readFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		dst reflect.Value
+		err error
+	}
+	results := make(chan result, 2)
+	run := func(q conn.Querier) {
+		v := reflect.New(dstValue.Elem().Type())
+		err := fn(ctx, q, v.Interface())
+		results <- result{dst: v, err: err}
+	}
+
+	go run(first)
+	pending := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case r := <-results:
+		if r.err != nil {
+			return r.err
+		}
+
+		dstValue.Elem().Set(r.dst.Elem())
+		return nil
+	case <-timer.C:
+		go run(second)
+		pending++
+	}
+
+	var firstErr error
+	for ; pending > 0; pending-- {
+		r := <-results
+		if r.err == nil {
+			dstValue.Elem().Set(r.dst.Elem())
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = r.err
+		}
+	}
+
+	return firstErr
+}
+
+// hedgeTarget returns a replica other than the one serving q that replayed the LSN token in ctx.
+// It returns nil if q is served by the primary, the primary is never used for hedging.
+func (conn *Cluster) hedgeTarget(ctx context.Context, q conn.Querier) *pdb {
+	first := conn.nodeOf(q)
+	if first == nil || first == conn.primary() {
+		return nil
+	}
+
+	for _, db := range conn.candidates()[1:] {
+		if db != first && caughtUp(ctx, db) {
+			return db
+		}
+	}
+
+	return nil
+}
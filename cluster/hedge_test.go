@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matryer/is"
+)
+
+func TestHedge(t *testing.T) {
+	primary := &pdb{name: "primary", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+	slow := &pdb{name: "slow", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+	fast := &pdb{name: "fast", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+
+	newCluster := func(delay time.Duration) *Cluster {
+		return &Cluster{pdbs: []*pdb{primary, slow, fast}, hedgeDelay: delay}
+	}
+
+	// read answers with node name, the slow node waits for cancellation.
+	read := func(db *Cluster, canceled *atomic.Bool) readFunc {
+		return func(ctx context.Context, q conn.Querier, dst any) error {
+			node := db.nodeOf(q)
+			if node == slow {
+				select {
+				case <-ctx.Done():
+					canceled.Store(true)
+					return ctx.Err()
+				case <-time.After(time.Second):
+				}
+			}
+
+			*dst.(*[]string) = []string{node.name}
+			return nil
+		}
+	}
+
+	t.Run("slow read is hedged", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(10 * time.Millisecond)
+		var canceled atomic.Bool
+		dst := []string{"old"}
+
+		err := db.hedge(t.Context(), slow.querier, &dst, read(db, &canceled))
+		is.NoErr(err)
+		is.Equal(dst, []string{"fast"}) // result of the second replica
+
+		time.Sleep(10 * time.Millisecond)
+		is.True(canceled.Load()) // the loser is canceled
+	})
+
+	t.Run("fast read is not hedged", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(time.Second)
+		var calls atomic.Int32
+		var dst []string
+		err := db.hedge(t.Context(), fast.querier, &dst, func(_ context.Context, _ conn.Querier, dst any) error {
+			calls.Add(1)
+			*dst.(*[]string) = []string{"fast"}
+			return nil
+		})
+		is.NoErr(err)
+		is.Equal(dst, []string{"fast"})
+		is.Equal(calls.Load(), int32(1))
+	})
+
+	t.Run("disabled per call", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(10 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(WithHedgeDelay(t.Context(), 0), 50*time.Millisecond)
+		defer cancel()
+
+		var canceled atomic.Bool
+		var dst []string
+		err := db.hedge(ctx, slow.querier, &dst, read(db, &canceled))
+		is.True(errors.Is(err, context.DeadlineExceeded)) // nobody else was asked
+	})
+
+	t.Run("first error is returned", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(10 * time.Millisecond)
+		boom := errors.New("boom")
+		var dst []string
+		err := db.hedge(t.Context(), slow.querier, &dst, func(context.Context, conn.Querier, any) error {
+			return boom
+		})
+		is.Equal(err, boom)
+		is.Equal(dst, nil) // dst is untouched on error
+	})
+
+	t.Run("hedge target", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(10 * time.Millisecond)
+		is.Equal(db.hedgeTarget(t.Context(), slow.querier), fast)
+		is.Equal(db.hedgeTarget(t.Context(), primary.querier), nil) // reads sent to the primary are not hedged
+
+		slow.replayLSN.Store(100)
+		defer slow.replayLSN.Store(0)
+		ctx := NewLSNContext(t.Context(), 100)
+		is.Equal(db.hedgeTarget(ctx, slow.querier), nil) // fast did not replay the token
+	})
+
+	t.Run("reads in a transaction are not hedged", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(time.Millisecond)
+		var calls atomic.Int32
+		var dst []string
+		ctx := conn.NewTxContext(t.Context(), &pgxpool.Tx{})
+		err := db.hedge(ctx, slow.querier, &dst, func(_ context.Context, _ conn.Querier, dst any) error {
+			calls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			*dst.(*[]string) = []string{"slow"}
+			return nil
+		})
+		is.NoErr(err)
+		is.Equal(dst, []string{"slow"})
+		is.Equal(calls.Load(), int32(1))
+	})
+}
@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultLagCheckInterval = time.Second
+
+	// lagUnknown marks a node whose lag could not be determined.
+	lagUnknown = -1
+
+	// lagQuery returns replication lag in seconds and the last replayed LSN.
+	// Lag is zero on the primary and on a streaming replica that replayed everything it has received,
+	// so an idle primary does not make replicas look behind. A replica whose WAL receiver is not streaming
+	// may have replayed all it got long ago, its lag is the age of the last replayed transaction.
+	// Without pg_read_all_stats the receiver status is hidden and a running receiver counts as streaming.
+	lagQuery = `
+SELECT
+  CASE
+    WHEN NOT pg_is_in_recovery() THEN 0
+    WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()
+      AND EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE COALESCE(status, 'streaming') = 'streaming') THEN 0
+    ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+  END::float8 AS lag,
+  COALESCE(pg_last_wal_replay_lsn(), '0/0')::text AS replay_lsn`
+)
+
+// lagMonitor queries replication lag of every physical database each interval until ctx is canceled.
+func (conn *Cluster) lagMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			conn.checkLag(ctx, interval)
+		}
+	}
+}
+
+// checkLag queries replication lag of every physical database concurrently.
+func (conn *Cluster) checkLag(ctx context.Context, timeout time.Duration) {
+	pdbs, _ := conn.nodes()
+	_ = scatter(len(pdbs), func(i int) error {
+		queryCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		err := pdbs[i].queryLag(queryCtx)
+		if err != nil && ctx.Err() == nil {
+			pdbs[i].lag.Store(lagUnknown)
+		}
+
+		return nil
+	})
+}
+
+// queryLag fetches and stores replication lag and replay position of the node.
+func (db *pdb) queryLag(ctx context.Context) error {
+	var (
+		lag float64
+		lsn string
+	)
+	if err := db.pool.QueryRow(ctx, lagQuery).Scan(&lag, &lsn); err != nil {
+		return fmt.Errorf("query replication lag: %w", err)
+	}
+
+	replayLSN, err := ParseLSN(lsn)
+	if err != nil {
+		return err
+	}
+
+	db.lag.Store(int64(lag * float64(time.Second)))
+	db.replayLSN.Store(uint64(replayLSN))
+
+	return nil
+}
+
+// replicationLag returns the last observed replication lag of the node.
+// ok is false if lag is unknown.
+func (db *pdb) replicationLag() (time.Duration, bool) {
+	lag := db.lag.Load()
+	if lag == lagUnknown {
+		return 0, false
+	}
+
+	return time.Duration(lag), true
+}
+
+// withinLag reports whether the node is known to lag no more than maxLag.
+func (db *pdb) withinLag(maxLag time.Duration) bool {
+	lag, ok := db.replicationLag()
+	return ok && lag <= maxLag
+}
@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgmock"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/matryer/is"
+)
+
+func TestReplicaSkipsLagging(t *testing.T) {
+	is := is.New(t)
+
+	primary, one, two := &pdb{name: "primary"}, &pdb{name: "one"}, &pdb{name: "two"}
+	db := &Cluster{pdbs: []*pdb{primary, one, two}, maxLag: time.Second}
+
+	one.lag.Store(int64(500 * time.Millisecond))
+	two.lag.Store(int64(2 * time.Second))
+	is.Equal(db.candidates(), []*pdb{primary, one}) // replica behind the bound is skipped
+
+	one.lag.Store(lagUnknown)
+	is.Equal(db.candidates(), []*pdb{primary}) // replica with unknown lag is skipped
+
+	db.maxLag = 0
+	is.Equal(db.candidates(), []*pdb{primary, one, two}) // lag is ignored when bound is not set
+}
+
+func TestLagMonitor(t *testing.T) {
+	is := is.New(t)
+
+	db, err := Open(
+		[]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"},
+		WithMaxReplicationLag(time.Second),
+		WithLagCheckInterval(10*time.Millisecond),
+	)
+	is.NoErr(err)
+	defer db.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for db.Health()[1].Lag != lagUnknown && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	is.Equal(db.Health()[1].Lag, time.Duration(lagUnknown)) // lag of unreachable replica is unknown
+	is.Equal(db.nodeOf(db.Replica()), db.primary())         // reads fall back to primary
+}
+
+func TestLagCheckedAtOpen(t *testing.T) {
+	t.Run("replica is used once its lag is known", func(t *testing.T) {
+		is := is.New(t)
+
+		steps := []pgmock.Step{
+			pgmock.ExpectAnyMessage(&pgproto3.Query{}),
+			pgmock.SendMessage(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+				{Name: []byte("lag"), DataTypeOID: 701, DataTypeSize: 8, TypeModifier: -1},
+				{Name: []byte("replay_lsn"), DataTypeOID: 25, DataTypeSize: -1, TypeModifier: -1},
+			}}),
+			pgmock.SendMessage(&pgproto3.DataRow{Values: [][]byte{[]byte("0"), []byte("0/16")}}),
+			pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")}),
+			pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: 'I'}),
+			pgmock.ExpectMessage(&pgproto3.Terminate{}),
+		}
+
+		db, err := Open(
+			[]string{"host=127.0.0.1 port=1", testDatabase(t, steps...) + " default_query_exec_mode=simple_protocol"},
+			WithMaxReplicationLag(time.Second),
+			WithLagCheckInterval(time.Hour), // only the check at Open runs
+		)
+		is.NoErr(err)
+		defer db.Close()
+
+		is.Equal(db.Health()[1].Lag, time.Duration(0))
+		is.Equal(db.nodeOf(db.Replica()), db.pdbs[1])
+	})
+
+	t.Run("lag is unknown without monitoring", func(t *testing.T) {
+		is := is.New(t)
+
+		db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"})
+		is.NoErr(err)
+		defer db.Close()
+
+		is.Equal(db.Health()[1].Lag, time.Duration(lagUnknown))
+		is.Equal(db.Stats().Nodes[1].Lag, time.Duration(lagUnknown))
+	})
+}
@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestParseLSN(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LSN
+		wantErr bool
+	}{
+		{"0/0", 0, false},
+		{"16/B374D848", 0x16B374D848, false},
+		{"FFFFFFFF/FFFFFFFF", LSN(^uint64(0)), false},
+		{"", 0, true},
+		{"16B374D848", 0, true},
+		{"G/0", 0, true},
+		{"1/100000000", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			is := is.New(t)
+
+			got, err := ParseLSN(tt.in)
+			if tt.wantErr {
+				is.True(err != nil)
+				return
+			}
+
+			is.NoErr(err)
+			is.Equal(got, tt.want)
+			is.Equal(got.String(), tt.in)
+		})
+	}
+}
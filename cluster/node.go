@@ -0,0 +1,309 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultWeight = 1
+
+	// latencyDecay is the weight of the latest observation in the latency moving average.
+	latencyDecay = 0.3
+)
+
+// Node is a physical database of the cluster as seen by a Balancer.
+type Node interface {
+	// Name of the node in host:port form.
+	Name() string
+	// Weight of the node, see WithWeights.
+	Weight() int
+	// Stat returns connection pool statistics.
+	Stat() *pgxpool.Stat
+	// AcquiredConns returns the number of connections currently in use.
+	AcquiredConns() int32
+	// Latency returns exponentially weighted moving average of query durations, zero if nothing was observed.
+	Latency() time.Duration
+}
+
+var _ Node = &pdb{}
+
+type pdb struct {
+	name    string
+	weight  int
+	pool    *pgxpool.Pool
+	querier conn.Querier
+
+	unhealthy atomic.Bool
+	failures  atomic.Int32
+	lag       atomic.Int64
+	replayLSN atomic.Uint64
+	latency   atomic.Int64
+	draining  atomic.Bool
+	inflight  atomic.Int64
+	// removed is set once the node starts leaving the cluster, calls routed to it before are rejected.
+	removed atomic.Bool
+
+	queries      atomic.Int64
+	transactions atomic.Int64
+	errors       atomic.Int64
+}
+
+// NodeOption configures a node added with Cluster.AddNode.
+type NodeOption func(*pdb)
+
+// NodeWeight sets weight of the node, see WeightedRoundRobin.
+func NodeWeight(weight int) NodeOption {
+	return func(db *pdb) {
+		db.weight = weight
+	}
+}
+
+func newPDB(cluster *Cluster, pool *pgxpool.Pool, weight int) *pdb {
+	db := &pdb{
+		name:   nodeName(pool.Config()),
+		weight: weight,
+		pool:   pool,
+	}
+	db.lag.Store(lagUnknown)
+	db.querier = &nodeQuerier{Querier: conn.WrapConn(pool, cluster.ScanAPI(), conn.WithInterceptors(cluster.Interceptors()...)), db: db, cluster: cluster}
+
+	return db
+}
+
+func (db *pdb) Name() string {
+	return db.name
+}
+
+func (db *pdb) Weight() int {
+	return db.weight
+}
+
+func (db *pdb) Stat() *pgxpool.Stat {
+	return db.pool.Stat()
+}
+
+func (db *pdb) AcquiredConns() int32 {
+	return db.pool.Stat().AcquiredConns()
+}
+
+func (db *pdb) Latency() time.Duration {
+	return time.Duration(db.latency.Load())
+}
+
+// observeLatency adds query duration d to the latency moving average.
+func (db *pdb) observeLatency(d time.Duration) {
+	for {
+		current := db.latency.Load()
+		next := int64(d)
+		if current != 0 {
+			next = int64(latencyDecay*float64(d) + (1-latencyDecay)*float64(current))
+		}
+
+		if db.latency.CompareAndSwap(current, next) {
+			return
+		}
+	}
+}
+
+// nodeQuerier observes queries executed on a physical database.
+type nodeQuerier struct {
+	conn.Querier
+	db      *pdb
+	cluster *Cluster
+}
+
+func (q *nodeQuerier) Select(ctx context.Context, dst any, sql string, args ...any) error {
+	done, err := q.track()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	start := time.Now()
+	err = q.Querier.Select(ctx, dst, sql, args...)
+	q.observe(ctx, MethodSelect, start, err)
+
+	return err
+}
+
+func (q *nodeQuerier) Get(ctx context.Context, dst any, sql string, args ...any) error {
+	done, err := q.track()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	start := time.Now()
+	err = q.Querier.Get(ctx, dst, sql, args...)
+	q.observe(ctx, MethodGet, start, err)
+
+	return err
+}
+
+func (q *nodeQuerier) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	done, err := q.track()
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	start := time.Now()
+	rows, err := q.Querier.Exec(ctx, sql, args...)
+	q.observe(ctx, MethodExec, start, err)
+
+	return rows, err
+}
+
+// Query keeps the call in flight until the returned rows are closed or read to the end,
+// the call is observed then, so latency includes reading the rows.
+func (q *nodeQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	done, err := q.track()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	rows, err := q.Querier.Query(ctx, sql, args...)
+	if err != nil {
+		q.observe(ctx, MethodQuery, start, err)
+		done()
+		return nil, err
+	}
+
+	return &trackedRows{Rows: rows, done: func(err error) {
+		q.observe(ctx, MethodQuery, start, err)
+		done()
+	}}, nil
+}
+
+func (q *nodeQuerier) SendBatch(ctx context.Context, b *conn.Batch) error {
+	done, err := q.track()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	start := time.Now()
+	err = q.Querier.SendBatch(ctx, b)
+	q.observe(ctx, MethodBatch, start, err)
+
+	return err
+}
+
+func (q *nodeQuerier) CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error) {
+	done, err := q.track()
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	start := time.Now()
+	copied, err := q.Querier.CopyFrom(ctx, table, rows)
+	q.observe(ctx, MethodCopy, start, err)
+
+	return copied, err
+}
+
+func (q *nodeQuerier) Tx(ctx context.Context, f func(q conn.Querier) error, opts ...conn.TxOption) error {
+	done, err := q.track()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	q.db.transactions.Add(1)
+	start := time.Now()
+
+	var fErr error
+	err = q.Querier.Tx(ctx, func(tx conn.Querier) error {
+		fErr = f(tx)
+		return fErr
+	}, opts...)
+	q.count(err)
+
+	outcome := TxCommit
+	switch {
+	case fErr != nil:
+		outcome = TxRollback
+	case err != nil:
+		outcome = TxError
+	}
+	q.cluster.notify(ctx, q.db, Event{Method: MethodTx, Duration: time.Since(start), Err: err, Outcome: outcome})
+
+	return err
+}
+
+// track counts the call as in flight until the returned func is called.
+// It fails with ErrNodeRemoved if the node left the cluster after the call was routed to it.
+// The counter is raised before removal is checked, so DrainNode either sees the call or the call sees removal.
+func (q *nodeQuerier) track() (func(), error) {
+	q.db.inflight.Add(1)
+	if q.db.removed.Load() {
+		q.db.inflight.Add(-1)
+		return nil, fmt.Errorf("%w: %s", ErrNodeRemoved, q.db.name)
+	}
+
+	return func() { q.db.inflight.Add(-1) }, nil
+}
+
+// observe counts the query, records latency of a successful one and notifies the observers.
+// Failed and canceled queries, e.g. losers of hedged reads, would skew the average.
+func (q *nodeQuerier) observe(ctx context.Context, method Method, start time.Time, err error) {
+	d := time.Since(start)
+	q.db.queries.Add(1)
+	q.count(err)
+	if err == nil {
+		q.db.observeLatency(d)
+	}
+
+	q.cluster.notify(ctx, q.db, Event{Method: method, Duration: d, Err: err})
+}
+
+// count records err unless the call was canceled.
+func (q *nodeQuerier) count(err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		q.db.errors.Add(1)
+	}
+}
+
+// trackedRows calls done with the rows error once when closed,
+// either explicitly or by pgx once Next returns false.
+type trackedRows struct {
+	pgx.Rows
+	done func(err error)
+	once sync.Once
+}
+
+func (r *trackedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+
+	r.finish()
+	return false
+}
+
+func (r *trackedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *trackedRows) finish() {
+	r.once.Do(func() { r.done(r.Rows.Err()) })
+}
+
+// nodeName returns host:port of the physical database.
+func nodeName(config *pgxpool.Config) string {
+	return net.JoinHostPort(config.ConnConfig.Host, strconv.Itoa(int(config.ConnConfig.Port)))
+}
@@ -0,0 +1,94 @@
+package cluster
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+)
+
+// Method is the Querier method that ran on a physical database.
+type Method = conn.Method
+
+const (
+	MethodSelect = conn.MethodSelect
+	MethodGet    = conn.MethodGet
+	MethodExec   = conn.MethodExec
+	MethodQuery  = conn.MethodQuery
+	MethodBatch  = conn.MethodBatch
+	MethodCopy   = conn.MethodCopy
+	MethodTx     = conn.MethodTx
+)
+
+// TxOutcome is how a transaction finished.
+type TxOutcome string
+
+const (
+	// TxCommit is a committed transaction.
+	TxCommit TxOutcome = "commit"
+	// TxRollback is a transaction rolled back because its function returned an error.
+	TxRollback TxOutcome = "rollback"
+	// TxError is a transaction that failed to begin or commit.
+	TxError TxOutcome = "error"
+)
+
+// Event describes a finished Select, Get, Exec, Query, SendBatch, CopyFrom or Tx call on a physical database.
+// A Query call finishes once its rows are closed, so its duration includes reading the rows.
+type Event struct {
+	// Node name in host:port form.
+	Node string
+	// Role of the node when the call finished.
+	Role Role
+	// Method that was called.
+	Method Method
+	// Duration of the call, for Tx it includes the transaction function.
+	Duration time.Duration
+	// Err returned by the call.
+	Err error
+	// Outcome of the transaction, empty unless Method is MethodTx.
+	Outcome TxOutcome
+}
+
+// Observer receives events of calls on physical databases.
+// Observe is called synchronously on the calling goroutine, so it must be fast and safe for concurrent use.
+type Observer interface {
+	Observe(ctx context.Context, event Event)
+}
+
+// ObserverFunc is an adapter to use ordinary functions as Observer.
+type ObserverFunc func(ctx context.Context, event Event)
+
+// Observe calls f(ctx, event).
+func (f ObserverFunc) Observe(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+// AddObserver registers o to receive events of every physical database, including nodes added later.
+func (conn *Cluster) AddObserver(o Observer) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	conn.observers = append(slices.Clip(conn.observers), o)
+}
+
+// notify passes event of db to the observers.
+func (conn *Cluster) notify(ctx context.Context, db *pdb, event Event) {
+	conn.mu.RLock()
+	observers, primary := conn.observers, conn.pdbs[conn.primaryIdx]
+	conn.mu.RUnlock()
+
+	if len(observers) == 0 {
+		return
+	}
+
+	event.Node = db.name
+	event.Role = RoleReplica
+	if db == primary {
+		event.Role = RolePrimary
+	}
+
+	for _, o := range observers {
+		o.Observe(ctx, event)
+	}
+}
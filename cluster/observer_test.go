@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/jackc/pgmock"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/matryer/is"
+)
+
+func commandSteps(query, tag string, txStatus byte) []pgmock.Step {
+	return []pgmock.Step{
+		pgmock.ExpectMessage(&pgproto3.Query{String: query}),
+		pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte(tag)}),
+		pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: txStatus}),
+	}
+}
+
+func TestObserver(t *testing.T) {
+	is := is.New(t)
+
+	var steps []pgmock.Step
+	steps = append(steps, commandSteps("begin", "BEGIN", 'T')...)
+	steps = append(steps, commandSteps("rollback", "ROLLBACK", 'I')...)
+	steps = append(steps, commandSteps("begin", "BEGIN", 'T')...)
+	steps = append(steps, commandSteps("commit", "COMMIT", 'I')...)
+	steps = append(steps, commandSteps("DELETE FROM users", "DELETE 2", 'I')...)
+	steps = append(steps, pgmock.ExpectMessage(&pgproto3.Terminate{}))
+
+	db, err := Open([]string{
+		testDatabase(t, steps...) + " default_query_exec_mode=simple_protocol",
+		"host=127.0.0.1 port=1",
+	})
+	is.NoErr(err)
+	defer db.Close()
+
+	var (
+		mu     sync.Mutex
+		events []Event
+	)
+	db.AddObserver(ObserverFunc(func(_ context.Context, e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}))
+
+	errAbort := errors.New("abort")
+	is.Equal(db.Tx(t.Context(), func(conn.Querier) error { return errAbort }), errAbort)
+	is.NoErr(db.Tx(t.Context(), func(conn.Querier) error { return nil }))
+
+	rows, err := db.Exec(t.Context(), "DELETE FROM users")
+	is.NoErr(err)
+	is.Equal(rows, int64(2))
+
+	err = db.pdbs[1].querier.Tx(t.Context(), func(conn.Querier) error { return nil })
+	is.True(err != nil) // replica is unreachable
+
+	is.Equal(len(events), 4)
+	for i, want := range []Event{
+		{Node: db.pdbs[0].name, Role: RolePrimary, Method: MethodTx, Outcome: TxRollback},
+		{Node: db.pdbs[0].name, Role: RolePrimary, Method: MethodTx, Outcome: TxCommit},
+		{Node: db.pdbs[0].name, Role: RolePrimary, Method: MethodExec},
+		{Node: "127.0.0.1:1", Role: RoleReplica, Method: MethodTx, Outcome: TxError},
+	} {
+		got := events[i]
+		is.True(got.Duration > 0)
+		got.Duration, got.Err = 0, nil
+		is.Equal(got, want)
+	}
+	is.True(errors.Is(events[0].Err, errAbort))
+	is.True(events[3].Err != nil)
+}
@@ -1,17 +1,47 @@
 package cluster
 
-import "github.com/georgysavva/scany/v2/pgxscan"
+import (
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
 
 // Options for cluster.
 type Options struct {
 	Picker  ConnPicker
 	ScanAPI *pgxscan.API
+	// Balancer picks a replica for reads. Round-robin is used if nil.
+	Balancer Balancer
+	// Weights of physical databases in configuration order, see WeightedRoundRobin.
+	Weights []int
+	// HealthCheckInterval is the period between health checks. Zero disables health checking.
+	HealthCheckInterval time.Duration
+	// HealthCheckThreshold is the number of consecutive failed checks after which a node is ejected.
+	HealthCheckThreshold int
+	// MaxReplicationLag is the maximum lag of a replica eligible for reads. Zero disables lag checking.
+	MaxReplicationLag time.Duration
+	// LagCheckInterval is the period between replication lag checks.
+	LagCheckInterval time.Duration
+	// CausalReadTimeout is how long reads carrying an LSN token wait for a replica to catch up
+	// before falling back to the primary.
+	CausalReadTimeout time.Duration
+	// ReadRetry configures retries of Select and Get failed with connection errors. Reads are not retried by default.
+	ReadRetry RetryPolicy
+	// HedgeDelay is how long Select and Get wait for a replica before issuing the same read to another one.
+	// Zero disables hedging.
+	HedgeDelay time.Duration
+	// DiscoveryInterval is the period between primary discovery runs. Zero disables primary discovery.
+	DiscoveryInterval time.Duration
+	// Interceptors run around calls on every physical database, see conn.WithInterceptors.
+	Interceptors []conn.Interceptor
 }
 
 // Option func.
 type Option func(*Options)
 
 // WithConnPicker sets connection picker for Select and Get.
+// By default statements are routed as with SQLConnPicker.
 func WithConnPicker(picker ConnPicker) Option {
 	return func(o *Options) {
 		if picker != nil {
@@ -28,3 +58,110 @@ func WithScanAPI(api *pgxscan.API) Option {
 		}
 	}
 }
+
+// WithHealthCheck enables background health checking.
+// Each physical db is pinged every interval, a replica failing threshold
+// consecutive checks stops receiving reads until a check succeeds again.
+func WithHealthCheck(interval time.Duration, threshold int) Option {
+	return func(o *Options) {
+		if interval > 0 {
+			o.HealthCheckInterval = interval
+		}
+		if threshold > 0 {
+			o.HealthCheckThreshold = threshold
+		}
+	}
+}
+
+// WithMaxReplicationLag enables replication lag monitoring.
+// Replicas lagging behind the primary more than d are skipped by Replica and the default ConnPicker,
+// the primary is used when no replica qualifies.
+// A replica disconnected from the primary is measured by the age of its last replayed transaction,
+// so after a long enough write pause on the primary it is skipped too.
+func WithMaxReplicationLag(d time.Duration) Option {
+	return func(o *Options) {
+		if d > 0 {
+			o.MaxReplicationLag = d
+		}
+	}
+}
+
+// WithLagCheckInterval sets the period between replication lag checks.
+func WithLagCheckInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		if interval > 0 {
+			o.LagCheckInterval = interval
+		}
+	}
+}
+
+// WithCausalReadTimeout sets how long Get and Select with an LSN token in context
+// wait for a replica to replay the token position before falling back to the primary.
+// By default replicas are checked once. See NewLSNContext.
+func WithCausalReadTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		if d > 0 {
+			o.CausalReadTimeout = d
+		}
+	}
+}
+
+// WithPrimaryDiscovery enables primary detection with pg_is_in_recovery().
+// Roles are detected on open, every interval and right after Exec or Tx fail
+// because the primary became read-only, so writes follow a failover without restart.
+func WithPrimaryDiscovery(interval time.Duration) Option {
+	return func(o *Options) {
+		if interval > 0 {
+			o.DiscoveryInterval = interval
+		}
+	}
+}
+
+// WithBalancer sets the strategy picking a replica for reads.
+// See WeightedRoundRobin, LeastConnections and LowestLatency.
+func WithBalancer(balancer Balancer) Option {
+	return func(o *Options) {
+		if balancer != nil {
+			o.Balancer = balancer
+		}
+	}
+}
+
+// WithWeights sets weights of physical databases in configuration order.
+// Databases without a weight get weight 1.
+func WithWeights(weights ...int) Option {
+	return func(o *Options) {
+		o.Weights = weights
+	}
+}
+
+// WithReadRetry enables retries of Select and Get failed with connection errors,
+// see IsConnectionError. Each retry goes to a replica that was not tried yet and finally to the primary.
+// Reads forced to a node with WithPrimary or WithNode and reads the ConnPicker sent to the primary are not retried.
+func WithReadRetry(policy RetryPolicy) Option {
+	return func(o *Options) {
+		if policy.Backoff <= 0 {
+			policy.Backoff = defaultRetryBackoff
+		}
+		o.ReadRetry = policy
+	}
+}
+
+// WithHedging enables hedged reads: if a replica has not answered Select or Get within delay
+// the same read is issued to another replica, the first successful result is used and the other read is canceled.
+// Hedging can be tuned per call chain with WithHedgeDelay.
+func WithHedging(delay time.Duration) Option {
+	return func(o *Options) {
+		if delay > 0 {
+			o.HedgeDelay = delay
+		}
+	}
+}
+
+// WithInterceptors appends interceptors run around Select, Get, Exec, Query, SendBatch, CopyFrom and Tx calls
+// on every physical database, including nodes added later. Retried and hedged reads pass through the chain once per node.
+func WithInterceptors(interceptors ...conn.Interceptor) Option {
+	return func(o *Options) {
+		o.Interceptors = append(o.Interceptors, interceptors...)
+	}
+}
@@ -1,7 +1,33 @@
 package cluster
 
-import "github.com/MrEhbr/pgxext/v2/conn"
+import (
+	"context"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+)
+
+// SQLConnPicker returns ConnPicker that sends statements a read-only replica can't run to the primary:
+// locking reads (FOR UPDATE, FOR SHARE...), data-modifying statements and CTEs, SELECT INTO
+// and calls of functions like nextval or pg_advisory_lock. Everything else goes to a replica.
+// functions extend the list of function names that need the primary, they are matched case-insensitively
+// and without schema.
+// Statements are tokenized, so keywords inside comments, string literals and quoted identifiers are ignored.
+func SQLConnPicker(functions ...string) ConnPicker {
+	analyzer := newSQLAnalyzer(functions)
+	return func(_ context.Context, db Conn, sql string) conn.Querier {
+		if analyzer.needsPrimary(sql) {
+			return db.Primary()
+		}
+
+		return db.Replica()
+	}
+}
+
+// defaultConnPicker is SQLConnPicker with the default function list.
+func defaultConnPicker(_ context.Context, db Conn, sql string) conn.Querier {
+	if (sqlAnalyzer{}).needsPrimary(sql) {
+		return db.Primary()
+	}
 
-func defaultConnPicker(db Conn, _ string) conn.Querier {
 	return db.Replica()
 }
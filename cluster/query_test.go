@@ -0,0 +1,267 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/jackc/pgmock"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/matryer/is"
+)
+
+func TestIterate(t *testing.T) {
+	is := is.New(t)
+
+	const query = "SELECT n FROM numbers"
+	steps := []pgmock.Step{
+		pgmock.ExpectMessage(&pgproto3.Query{String: query}),
+		pgmock.SendMessage(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+			{Name: []byte("n"), DataTypeOID: 23, DataTypeSize: 4, TypeModifier: -1},
+		}}),
+	}
+	for i := 1; i <= 3; i++ {
+		steps = append(steps, pgmock.SendMessage(&pgproto3.DataRow{Values: [][]byte{[]byte(strconv.Itoa(i))}}))
+	}
+	steps = append(steps,
+		pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 3")}),
+		pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: 'I'}),
+		pgmock.ExpectMessage(&pgproto3.Terminate{}),
+	)
+
+	db, err := Open([]string{
+		"host=127.0.0.1 port=1",
+		testDatabase(t, steps...) + " default_query_exec_mode=simple_protocol",
+	})
+	is.NoErr(err)
+	defer db.Close()
+
+	replica := db.pdbs[1]
+	for n, err := range conn.Iterate[int](t.Context(), db, query) {
+		is.NoErr(err)
+		is.Equal(n, 1)
+		is.Equal(replica.inflight.Load(), int64(1)) // rows are read from the replica
+		is.Equal(replica.queries.Load(), int64(0))  // the call is observed once rows are closed
+		break
+	}
+
+	is.Equal(replica.inflight.Load(), int64(0)) // rows are closed on early break
+	is.Equal(replica.queries.Load(), int64(1))
+}
+
+func TestQueryRowsReadToEnd(t *testing.T) {
+	is := is.New(t)
+
+	const query = "SELECT n FROM numbers"
+	db, err := Open([]string{
+		"host=127.0.0.1 port=1",
+		testDatabase(t,
+			pgmock.ExpectMessage(&pgproto3.Query{String: query}),
+			pgmock.SendMessage(&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+				{Name: []byte("n"), DataTypeOID: 23, DataTypeSize: 4, TypeModifier: -1},
+			}}),
+			pgmock.SendMessage(&pgproto3.DataRow{Values: [][]byte{[]byte("1")}}),
+			pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")}),
+			pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: 'I'}),
+			pgmock.ExpectMessage(&pgproto3.Terminate{}),
+		) + " default_query_exec_mode=simple_protocol",
+	})
+	is.NoErr(err)
+	defer db.Close()
+
+	rows, err := db.Query(t.Context(), query)
+	is.NoErr(err)
+
+	replica := db.pdbs[1]
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	is.NoErr(rows.Err())
+	is.Equal(count, 1)
+
+	// pgx closes exhausted rows by itself, the call must not wait for an explicit Close
+	is.Equal(replica.inflight.Load(), int64(0))
+	is.Equal(replica.queries.Load(), int64(1))
+
+	rows.Close()
+	is.Equal(replica.queries.Load(), int64(1)) // observed once
+}
+
+func TestCursorOnReplica(t *testing.T) {
+	is := is.New(t)
+
+	anyQuery := func(tag string, responses ...pgproto3.BackendMessage) []pgmock.Step {
+		steps := []pgmock.Step{pgmock.ExpectAnyMessage(&pgproto3.Query{})}
+		for _, r := range responses {
+			steps = append(steps, pgmock.SendMessage(r))
+		}
+		return append(steps,
+			pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte(tag)}),
+			pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: 'T'}),
+		)
+	}
+
+	var steps []pgmock.Step
+	steps = append(steps, commandSteps("begin", "BEGIN", 'T')...)
+	steps = append(steps, anyQuery("DECLARE CURSOR")...)
+	steps = append(steps, anyQuery("FETCH 2",
+		&pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+			{Name: []byte("n"), DataTypeOID: 23, DataTypeSize: 4, TypeModifier: -1},
+		}},
+		&pgproto3.DataRow{Values: [][]byte{[]byte("1")}},
+		&pgproto3.DataRow{Values: [][]byte{[]byte("2")}},
+	)...)
+	steps = append(steps, anyQuery("CLOSE CURSOR")...)
+	steps = append(steps, commandSteps("commit", "COMMIT", 'I')...)
+	steps = append(steps, pgmock.ExpectMessage(&pgproto3.Terminate{}))
+
+	db, err := Open([]string{
+		"host=127.0.0.1 port=1",
+		testDatabase(t, steps...) + " default_query_exec_mode=simple_protocol",
+	})
+	is.NoErr(err)
+	defer db.Close()
+
+	for chunk, err := range conn.Cursor[int](t.Context(), db.Replica(), 2, "SELECT n FROM numbers") {
+		is.NoErr(err)
+		is.Equal(chunk, []int{1, 2})
+		break
+	}
+
+	is.Equal(db.pdbs[1].transactions.Load(), int64(1)) // cursor transaction ran on the replica
+	is.Equal(db.pdbs[1].errors.Load(), int64(0))
+}
+
+func TestSendBatch(t *testing.T) {
+	t.Run("read-only batch goes to a replica", func(t *testing.T) {
+		is := is.New(t)
+
+		intField := &pgproto3.RowDescription{Fields: []pgproto3.FieldDescription{
+			{Name: []byte("n"), DataTypeOID: 23, DataTypeSize: 4, TypeModifier: -1},
+		}}
+		steps := []pgmock.Step{
+			pgmock.ExpectAnyMessage(&pgproto3.Query{}),
+			pgmock.SendMessage(intField),
+			pgmock.SendMessage(&pgproto3.DataRow{Values: [][]byte{[]byte("1")}}),
+			pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 1")}),
+			pgmock.SendMessage(intField),
+			pgmock.SendMessage(&pgproto3.DataRow{Values: [][]byte{[]byte("2")}}),
+			pgmock.SendMessage(&pgproto3.DataRow{Values: [][]byte{[]byte("3")}}),
+			pgmock.SendMessage(&pgproto3.CommandComplete{CommandTag: []byte("SELECT 2")}),
+			pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: 'I'}),
+			pgmock.ExpectMessage(&pgproto3.Terminate{}),
+		}
+
+		db, err := Open([]string{
+			"host=127.0.0.1 port=1",
+			testDatabase(t, steps...) + " default_query_exec_mode=simple_protocol",
+		})
+		is.NoErr(err)
+		defer db.Close()
+
+		var (
+			one  int
+			rest []int
+		)
+		b := (&conn.Batch{}).Get(&one, "SELECT 1").Select(&rest, "SELECT n FROM numbers")
+		is.NoErr(db.SendBatch(t.Context(), b))
+		is.Equal(one, 1)
+		is.Equal(rest, []int{2, 3})
+		is.Equal(db.pdbs[1].queries.Load(), int64(1))
+	})
+
+	t.Run("batch with writes goes to the primary", func(t *testing.T) {
+		is := is.New(t)
+
+		db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"})
+		is.NoErr(err)
+		defer db.Close()
+
+		var n int
+		b := (&conn.Batch{}).Get(&n, "SELECT 1").Exec(nil, "DELETE FROM users")
+		is.True(db.SendBatch(t.Context(), b) != nil) // nodes are unreachable
+		is.Equal(db.pdbs[0].queries.Load(), int64(1))
+		is.Equal(db.pdbs[1].queries.Load(), int64(0))
+	})
+
+	t.Run("batch honors picker functions", func(t *testing.T) {
+		is := is.New(t)
+
+		db, err := Open(
+			[]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"},
+			WithConnPicker(SQLConnPicker("create_user")),
+		)
+		is.NoErr(err)
+		defer db.Close()
+
+		var id int
+		b := (&conn.Batch{}).Get(&id, "SELECT 1").Get(&id, "SELECT create_user($1)", "bob")
+		is.True(db.SendBatch(t.Context(), b) != nil) // nodes are unreachable
+		is.Equal(db.pdbs[0].queries.Load(), int64(1))
+		is.Equal(db.pdbs[1].queries.Load(), int64(0))
+	})
+
+	t.Run("classifying a batch does not advance the balancer", func(t *testing.T) {
+		is := is.New(t)
+
+		db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2", "host=127.0.0.1 port=3"})
+		is.NoErr(err)
+		defer db.Close()
+
+		var n int
+		b := (&conn.Batch{}).Get(&n, "SELECT 1").Get(&n, "SELECT 2").Get(&n, "SELECT 3")
+		is.True(db.batchReadOnly(t.Context(), b))
+		is.Equal(db.count, uint64(0)) // no replica was picked
+	})
+}
+
+func TestCopyFromOnPrimary(t *testing.T) {
+	is := is.New(t)
+
+	db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"})
+	is.NoErr(err)
+	defer db.Close()
+
+	type user struct{ ID int }
+	_, err = db.CopyFrom(t.Context(), pgx.Identifier{"users"}, []user{{ID: 1}})
+	is.True(err != nil) // nodes are unreachable
+	is.Equal(db.pdbs[0].queries.Load(), int64(1))
+	is.Equal(db.pdbs[1].queries.Load(), int64(0))
+}
+
+func TestReadOnlyTxOnReplica(t *testing.T) {
+	is := is.New(t)
+
+	var steps []pgmock.Step
+	steps = append(steps, commandSteps("begin isolation level repeatable read read only", "BEGIN", 'T')...)
+	steps = append(steps, commandSteps("commit", "COMMIT", 'I')...)
+	steps = append(steps, pgmock.ExpectMessage(&pgproto3.Terminate{}))
+
+	db, err := Open([]string{
+		"host=127.0.0.1 port=1",
+		testDatabase(t, steps...) + " default_query_exec_mode=simple_protocol",
+	})
+	is.NoErr(err)
+	defer db.Close()
+
+	err = db.Tx(t.Context(), func(conn.Querier) error { return nil }, conn.IsolationLevel(pgx.RepeatableRead), conn.ReadOnly())
+	is.NoErr(err)
+	is.Equal(db.pdbs[0].transactions.Load(), int64(0))
+	is.Equal(db.pdbs[1].transactions.Load(), int64(1))
+}
+
+func TestSerializableTxOnPrimary(t *testing.T) {
+	is := is.New(t)
+
+	db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2"})
+	is.NoErr(err)
+	defer db.Close()
+
+	err = db.Tx(t.Context(), func(conn.Querier) error { return nil },
+		conn.IsolationLevel(pgx.Serializable), conn.ReadOnly(), conn.Deferrable())
+	is.True(err != nil)                                // nodes are unreachable
+	is.Equal(db.pdbs[0].transactions.Load(), int64(1)) // hot standbys reject serializable transactions
+	is.Equal(db.pdbs[1].transactions.Load(), int64(0))
+}
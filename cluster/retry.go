@@ -0,0 +1,146 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const defaultRetryBackoff = 10 * time.Millisecond
+
+// RetryPolicy configures retries of reads failed because of a connection error.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, it doubles with each next attempt.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+}
+
+// delay returns the pause before attempt, attempts are counted from 1.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 2; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+
+	return d
+}
+
+// IsConnectionError reports whether err is caused by a broken or refused connection
+// rather than by the statement itself, so the statement can be retried on another node.
+func IsConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrNodeRemoved) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "57P01", "57P02", "57P03": // admin_shutdown, crash_shutdown, cannot_connect_now
+			return true
+		}
+
+		return strings.HasPrefix(pgErr.Code, "08") // connection_exception class
+	}
+
+	var (
+		connectErr *pgconn.ConnectError
+		netErr     net.Error
+	)
+
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		pgconn.SafeToRetry(err)
+}
+
+// read runs fn on the querier routed for sql and retries it on other nodes
+// according to the retry policy while it fails with connection errors.
+// Replicas that replayed the LSN token in ctx are tried first and the primary last, reads in a transaction
+// from ctx are not retried. A read routed to the primary is not retried either, the ConnPicker may have sent it there
+// because it writes or locks rows, which replicas can't do and which may already be done.
+// Each attempt may be hedged, see WithHedging.
+func (conn *Cluster) read(ctx context.Context, sql string, dst any, fn readFunc) error {
+	q, err := conn.route(ctx, sql)
+	if err != nil {
+		return err
+	}
+
+	err = conn.hedge(ctx, q, dst, fn)
+	first := conn.nodeOf(q)
+	if conn.retry.MaxAttempts <= 1 || pinned(ctx) || inTx(ctx) || first == conn.primary() {
+		return err
+	}
+
+	tried := []*pdb{first}
+	for attempt := 2; attempt <= conn.retry.MaxAttempts && IsConnectionError(err) && ctx.Err() == nil; attempt++ {
+		next := conn.retryTarget(ctx, tried)
+		if next == nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(conn.retry.delay(attempt)):
+		}
+
+		tried = append(tried, next)
+		err = conn.hedge(ctx, next.querier, dst, fn)
+	}
+
+	return err
+}
+
+// selectFunc returns readFunc calling Select.
+func selectFunc(sql string, args []any) readFunc {
+	return func(ctx context.Context, q conn.Querier, dst any) error {
+		return q.Select(ctx, dst, sql, args...)
+	}
+}
+
+// getFunc returns readFunc calling Get.
+func getFunc(sql string, args []any) readFunc {
+	return func(ctx context.Context, q conn.Querier, dst any) error {
+		return q.Get(ctx, dst, sql, args...)
+	}
+}
+
+// retryTarget returns a read candidate that was not tried yet, replicas that replayed the LSN token in ctx first.
+func (conn *Cluster) retryTarget(ctx context.Context, tried []*pdb) *pdb {
+	candidates := conn.candidates()
+	for _, db := range candidates[1:] {
+		if !slices.Contains(tried, db) && caughtUp(ctx, db) {
+			return db
+		}
+	}
+
+	if !slices.Contains(tried, candidates[0]) {
+		return candidates[0]
+	}
+
+	return nil
+}
+
+// pinned reports whether routing hint in ctx forces a particular node.
+func pinned(ctx context.Context) bool {
+	hint, ok := ctx.Value(routeKey).(routeHint)
+	return ok && (hint.kind == routePrimary || hint.kind == routeNode)
+}
@@ -0,0 +1,175 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matryer/is"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"sql error", &pgconn.PgError{Code: "42P01"}, false},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"cannot connect now", &pgconn.PgError{Code: "57P03"}, true},
+		{"connection failure", fmt.Errorf("query: %w", &pgconn.PgError{Code: "08006"}), true},
+		{"network error", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("no rows in result set"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(IsConnectionError(tt.err), tt.want)
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	is := is.New(t)
+
+	p := RetryPolicy{Backoff: time.Millisecond, MaxBackoff: 3 * time.Millisecond}
+	is.Equal(p.delay(2), time.Millisecond)
+	is.Equal(p.delay(3), 2*time.Millisecond)
+	is.Equal(p.delay(4), 3*time.Millisecond) // capped
+	is.Equal(p.delay(10), 3*time.Millisecond)
+
+	p = RetryPolicy{Backoff: 5 * time.Millisecond, MaxBackoff: 3 * time.Millisecond}
+	is.Equal(p.delay(2), 3*time.Millisecond) // first delay is capped too
+}
+
+func TestReadRetry(t *testing.T) {
+	primary := &pdb{name: "primary", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+	one := &pdb{name: "one", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+	two := &pdb{name: "two", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+
+	newCluster := func(policy RetryPolicy) *Cluster {
+		return &Cluster{
+			pdbs:   []*pdb{primary, one, two},
+			picker: func(context.Context, Conn, string) conn.Querier { return one.querier },
+			retry:  policy,
+		}
+	}
+
+	// failing returns read failing on the given nodes and recording the order of attempts.
+	failing := func(db *Cluster, attempts *[]*pdb, err error, failed ...*pdb) readFunc {
+		return func(_ context.Context, q conn.Querier, _ any) error {
+			node := db.nodeOf(q)
+			*attempts = append(*attempts, node)
+			for _, f := range failed {
+				if f == node {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	connErr := &pgconn.PgError{Code: "57P01"}
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	t.Run("replicas first then primary", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(policy)
+		var attempts []*pdb
+		err := db.read(t.Context(), "SELECT 1", nil, failing(db, &attempts, connErr, one, two))
+		is.NoErr(err)
+		is.Equal(attempts, []*pdb{one, two, primary})
+	})
+
+	t.Run("bounded attempts", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+		var attempts []*pdb
+		err := db.read(t.Context(), "SELECT 1", nil, failing(db, &attempts, connErr, one, two, primary))
+		is.True(errors.Is(err, connErr))
+		is.Equal(attempts, []*pdb{one, two})
+	})
+
+	t.Run("sql errors are not retried", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(policy)
+		var attempts []*pdb
+		sqlErr := &pgconn.PgError{Code: "42P01"}
+		err := db.read(t.Context(), "SELECT 1", nil, failing(db, &attempts, sqlErr, one))
+		is.True(errors.Is(err, sqlErr))
+		is.Equal(attempts, []*pdb{one})
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(RetryPolicy{})
+		var attempts []*pdb
+		err := db.read(t.Context(), "SELECT 1", nil, failing(db, &attempts, connErr, one))
+		is.True(errors.Is(err, connErr))
+		is.Equal(attempts, []*pdb{one})
+	})
+
+	t.Run("pinned reads are not retried", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(policy)
+		var attempts []*pdb
+		err := db.read(WithNode(t.Context(), "one"), "SELECT 1", nil, failing(db, &attempts, connErr, one))
+		is.True(errors.Is(err, connErr))
+		is.Equal(attempts, []*pdb{one})
+	})
+
+	t.Run("replicas behind the LSN token are skipped", func(t *testing.T) {
+		is := is.New(t)
+
+		one.replayLSN.Store(100)
+		defer one.replayLSN.Store(0)
+
+		db := newCluster(policy)
+		var attempts []*pdb
+		ctx := NewLSNContext(t.Context(), 100)
+		err := db.read(ctx, "SELECT 1", nil, failing(db, &attempts, connErr, one))
+		is.NoErr(err)
+		is.Equal(attempts, []*pdb{one, primary}) // two did not replay the token
+	})
+
+	t.Run("reads routed to the primary are not retried", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(policy)
+		db.picker = SQLConnPicker()
+		var attempts []*pdb
+		err := db.read(t.Context(), "WITH n AS (INSERT INTO users DEFAULT VALUES RETURNING id) SELECT id FROM n", nil,
+			failing(db, &attempts, connErr, primary))
+		is.True(errors.Is(err, connErr))
+		is.Equal(attempts, []*pdb{primary}) // the write is neither sent to replicas nor repeated
+	})
+
+	t.Run("reads in a transaction are not retried", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(policy)
+		var attempts []*pdb
+		ctx := conn.NewTxContext(t.Context(), &pgxpool.Tx{})
+		err := db.read(ctx, "SELECT 1", nil, failing(db, &attempts, connErr, one))
+		is.True(errors.Is(err, connErr))
+		is.Equal(attempts, []*pdb{one})
+	})
+}
@@ -0,0 +1,230 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrUnknownNode is returned when a routing hint refers to a node that is not in the cluster.
+var ErrUnknownNode = errors.New("unknown node")
+
+type routeKeyType uint8
+
+const (
+	routeKey routeKeyType = 0
+)
+
+type routeKind uint8
+
+const (
+	routePrimary routeKind = iota + 1
+	routeReplica
+	routeNode
+)
+
+type routeHint struct {
+	kind routeKind
+	node string
+}
+
+// WithPrimary returns a new context forcing Select, Get and Replica queriers to use the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey, routeHint{kind: routePrimary})
+}
+
+// WithReplica returns a new context forcing Select, Get and Replica queriers to use a replica
+// regardless of ConnPicker decision. An LSN token in ctx is still honored, a replica behind it
+// is replaced by one that caught up or by the primary.
+func WithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeKey, routeHint{kind: routeReplica})
+}
+
+// WithNode returns a new context forcing Select, Get and Replica queriers to use the node with name in host:port form.
+// The node is used even if it has not replayed an LSN token in ctx.
+func WithNode(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, routeKey, routeHint{kind: routeNode, node: name})
+}
+
+// route returns querier for a read according to routing hints, ConnPicker and LSN token in ctx.
+func (conn *Cluster) route(ctx context.Context, sql string) (conn.Querier, error) {
+	db, ok, err := conn.hinted(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return db.querier, nil
+	}
+
+	return conn.causalReplica(ctx, conn.picker(ctx, conn, sql)), nil
+}
+
+// routeReadOnly returns querier for a read-only transaction according to routing hints and LSN token in ctx.
+func (conn *Cluster) routeReadOnly(ctx context.Context) (conn.Querier, error) {
+	db, ok, err := conn.hinted(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return db.querier, nil
+	}
+
+	return conn.causalReplica(ctx, conn.Replica()), nil
+}
+
+// replicaTx reports whether opts start a read-only transaction a replica can run.
+// Hot standbys reject serializable transactions, so those stay on the primary.
+func replicaTx(opts []conn.TxOption) bool {
+	txOpts := txOptions(opts)
+	return txOpts.AccessMode == pgx.ReadOnly && txOpts.IsoLevel != pgx.Serializable
+}
+
+// independentTx reports whether opts start a transaction committed on its own even inside a transaction in progress.
+func independentTx(opts []conn.TxOption) bool {
+	return txOptions(opts).Propagation == conn.PropagationRequiresNew
+}
+
+// txOptions returns opts applied to zero TxOptions.
+func txOptions(opts []conn.TxOption) *conn.TxOptions {
+	txOpts := &conn.TxOptions{}
+	for _, o := range opts {
+		o(txOpts)
+	}
+
+	return txOpts
+}
+
+// hinted returns the node forced by routing hint in ctx.
+// The replica hint is resolved to replica if it is not nil, or to another read candidate
+// if it has not replayed the LSN token in ctx, see causalReplica.
+func (conn *Cluster) hinted(ctx context.Context, replica *pdb) (*pdb, bool, error) {
+	hint, ok := ctx.Value(routeKey).(routeHint)
+	if !ok {
+		return nil, false, nil
+	}
+
+	switch hint.kind {
+	case routePrimary:
+		return conn.primary(), true, nil
+	case routeReplica:
+		if replica == nil {
+			replica = conn.pickReplica()
+		}
+		return conn.nodeOf(conn.causalReplica(ctx, replica.querier)), true, nil
+	case routeNode:
+		pdbs, _ := conn.nodes()
+		for _, db := range pdbs {
+			if db.name == hint.node && !db.draining.Load() {
+				return db, true, nil
+			}
+		}
+
+		return nil, false, fmt.Errorf("%w: %s", ErrUnknownNode, hint.node)
+	}
+
+	return nil, false, nil
+}
+
+var _ conn.Querier = &replicaQuerier{}
+
+// replicaQuerier is returned by Cluster.Replica, it uses the selected replica unless routing hint in ctx says otherwise.
+type replicaQuerier struct {
+	cluster *Cluster
+	db      *pdb
+}
+
+func (q *replicaQuerier) Select(ctx context.Context, dst any, sql string, args ...any) error {
+	db, err := q.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.querier.Select(ctx, dst, sql, args...)
+}
+
+func (q *replicaQuerier) Get(ctx context.Context, dst any, sql string, args ...any) error {
+	db, err := q.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.querier.Get(ctx, dst, sql, args...)
+}
+
+func (q *replicaQuerier) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	db, err := q.target(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return db.querier.Exec(ctx, sql, args...)
+}
+
+func (q *replicaQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	db, err := q.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.querier.Query(ctx, sql, args...)
+}
+
+func (q *replicaQuerier) SendBatch(ctx context.Context, b *conn.Batch) error {
+	db, err := q.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.querier.SendBatch(ctx, b)
+}
+
+func (q *replicaQuerier) CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error) {
+	db, err := q.target(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return db.querier.CopyFrom(ctx, table, rows)
+}
+
+func (q *replicaQuerier) Tx(ctx context.Context, f func(q conn.Querier) error, opts ...conn.TxOption) error {
+	db, err := q.target(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.querier.Tx(ctx, f, opts...)
+}
+
+// Conn returns connection of the target node, the selected replica is used if routing hint refers to unknown node.
+func (q *replicaQuerier) Conn(ctx context.Context) conn.PgxConn {
+	db, err := q.target(ctx)
+	if err != nil {
+		return q.db.querier.Conn(ctx)
+	}
+
+	return db.querier.Conn(ctx)
+}
+
+func (q *replicaQuerier) ScanAPI() *pgxscan.API {
+	return q.cluster.ScanAPI()
+}
+
+func (q *replicaQuerier) target(ctx context.Context) (*pdb, error) {
+	db, ok, err := q.cluster.hinted(ctx, q.db)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return db, nil
+	}
+	if q.db.removed.Load() {
+		return q.cluster.pickReplica(), nil
+	}
+
+	return q.db, nil
+}
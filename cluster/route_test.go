@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/matryer/is"
+)
+
+func TestRoute(t *testing.T) {
+	primary := &pdb{name: "primary:5432", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+	replica := &pdb{name: "replica:5432", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+
+	newCluster := func(picker ConnPicker) *Cluster {
+		return &Cluster{pdbs: []*pdb{primary, replica}, picker: picker}
+	}
+
+	t.Run("picker receives context", func(t *testing.T) {
+		is := is.New(t)
+
+		type key struct{}
+		db := newCluster(func(ctx context.Context, db Conn, _ string) conn.Querier {
+			if ctx.Value(key{}) != nil {
+				return db.Primary()
+			}
+			return db.Replica()
+		})
+
+		q, err := db.route(t.Context(), "SELECT 1")
+		is.NoErr(err)
+		is.Equal(db.nodeOf(q), replica)
+
+		q, err = db.route(context.WithValue(t.Context(), key{}, true), "SELECT 1")
+		is.NoErr(err)
+		is.Equal(db.nodeOf(q), primary)
+	})
+
+	t.Run("hints take precedence over picker", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(defaultConnPicker)
+
+		q, err := db.route(WithPrimary(t.Context()), "SELECT 1")
+		is.NoErr(err)
+		is.Equal(q, primary.querier)
+
+		db.picker = func(_ context.Context, db Conn, _ string) conn.Querier { return db.Primary() }
+		q, err = db.route(WithReplica(t.Context()), "SELECT 1")
+		is.NoErr(err)
+		is.Equal(q, replica.querier)
+
+		q, err = db.route(WithNode(t.Context(), "primary:5432"), "SELECT 1")
+		is.NoErr(err)
+		is.Equal(q, primary.querier)
+
+		_, err = db.route(WithNode(t.Context(), "unknown:5432"), "SELECT 1")
+		is.True(errors.Is(err, ErrUnknownNode))
+	})
+
+	t.Run("replica querier honors hints", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(defaultConnPicker)
+		q, ok := db.Replica().(*replicaQuerier)
+		is.True(ok)
+		is.Equal(q.db, replica)
+
+		target, err := q.target(t.Context())
+		is.NoErr(err)
+		is.Equal(target, replica)
+
+		target, err = q.target(WithPrimary(t.Context()))
+		is.NoErr(err)
+		is.Equal(target, primary)
+
+		target, err = q.target(WithNode(t.Context(), "primary:5432"))
+		is.NoErr(err)
+		is.Equal(target, primary)
+
+		_, err = q.target(WithNode(t.Context(), "unknown:5432"))
+		is.True(errors.Is(err, ErrUnknownNode))
+	})
+}
@@ -0,0 +1,233 @@
+package cluster
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind uint8
+
+const (
+	tokenWord  tokenKind = iota + 1 // unquoted identifier or keyword, lower-cased
+	tokenIdent                      // quoted identifier
+	tokenPunct                      // one of ( ) , ; .
+	tokenOther                      // literal, operator or parameter
+)
+
+type sqlToken struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits sql into tokens, comments are dropped and literals are collapsed into tokenOther.
+// It is not a parser, it only knows enough of PostgreSQL lexical structure to find keywords reliably.
+func tokenize(sql string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipBlockComment(sql, i)
+		case c == '\'':
+			i = skipQuoted(sql, i+1, '\'', false)
+			tokens = append(tokens, sqlToken{kind: tokenOther})
+		case (c == 'e' || c == 'E') && i+1 < len(sql) && sql[i+1] == '\'':
+			i = skipQuoted(sql, i+2, '\'', true)
+			tokens = append(tokens, sqlToken{kind: tokenOther})
+		case c == '"':
+			end := skipQuoted(sql, i+1, '"', false)
+			tokens = append(tokens, sqlToken{kind: tokenIdent, text: strings.ReplaceAll(sql[i+1:max(end-1, i+1)], `""`, `"`)})
+			i = end
+		case c == '$':
+			i = skipDollar(sql, i)
+			tokens = append(tokens, sqlToken{kind: tokenOther})
+		case c == '(' || c == ')' || c == ',' || c == ';' || c == '.':
+			tokens = append(tokens, sqlToken{kind: tokenPunct, text: sql[i : i+1]})
+			i++
+		case isIdentStart(sql[i:]):
+			end := i
+			for end < len(sql) && isIdentPart(sql[end:]) {
+				_, size := utf8.DecodeRuneInString(sql[end:])
+				end += size
+			}
+			tokens = append(tokens, sqlToken{kind: tokenWord, text: strings.ToLower(sql[i:end])})
+			i = end
+		default:
+			_, size := utf8.DecodeRuneInString(sql[i:])
+			tokens = append(tokens, sqlToken{kind: tokenOther})
+			i += size
+		}
+	}
+
+	return tokens
+}
+
+// skipBlockComment returns position after the block comment starting at i, block comments nest.
+func skipBlockComment(sql string, i int) int {
+	depth := 0
+	for i < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(sql[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+
+	return i
+}
+
+// skipQuoted returns position after the closing quote, doubled quotes are escapes.
+// Backslash escapes are honored in escape string constants.
+func skipQuoted(sql string, i int, quote byte, backslash bool) int {
+	for i < len(sql) {
+		switch {
+		case backslash && sql[i] == '\\':
+			i += 2
+		case sql[i] == quote && i+1 < len(sql) && sql[i+1] == quote:
+			i += 2
+		case sql[i] == quote:
+			return i + 1
+		default:
+			i++
+		}
+	}
+
+	return len(sql)
+}
+
+// skipDollar returns position after a positional parameter or a dollar-quoted string starting at i.
+func skipDollar(sql string, i int) int {
+	end := i + 1
+	for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+		end++
+	}
+	if end > i+1 {
+		return end // positional parameter
+	}
+
+	for end < len(sql) && sql[end] != '$' && isIdentPart(sql[end:]) {
+		end++
+	}
+	if end >= len(sql) || sql[end] != '$' {
+		return i + 1
+	}
+
+	tag := sql[i : end+1]
+	closing := strings.Index(sql[end+1:], tag)
+	if closing < 0 {
+		return len(sql)
+	}
+
+	return end + 1 + closing + len(tag)
+}
+
+func isIdentStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// sqlAnalyzer decides whether a statement must run on the primary.
+// The zero value knows only the default primary functions.
+type sqlAnalyzer struct {
+	functions map[string]struct{}
+}
+
+func newSQLAnalyzer(functions []string) sqlAnalyzer {
+	a := sqlAnalyzer{functions: make(map[string]struct{}, len(functions))}
+	for _, f := range functions {
+		a.functions[strings.ToLower(f)] = struct{}{}
+	}
+
+	return a
+}
+
+// isPrimaryFunction reports whether calling function needs the primary.
+func (a sqlAnalyzer) isPrimaryFunction(function string) bool {
+	switch function {
+	case "nextval", "setval", "txid_current", "pg_current_xact_id", "pg_current_wal_lsn", "pg_notify",
+		"pg_advisory_lock", "pg_advisory_xact_lock", "pg_try_advisory_lock", "pg_try_advisory_xact_lock":
+		return true
+	}
+
+	_, ok := a.functions[function]
+	return ok
+}
+
+// needsPrimary reports whether sql modifies data, takes row locks or calls one of the primary functions.
+// Statements other than SELECT, VALUES, TABLE, SHOW, EXPLAIN and read-only WITH queries need the primary.
+func (a sqlAnalyzer) needsPrimary(sql string) bool {
+	tokens := tokenize(sql)
+	statementStart := true
+	for i, t := range tokens {
+		if t.kind == tokenPunct && t.text == ";" {
+			statementStart = true
+			continue
+		}
+
+		if statementStart && t.kind == tokenWord {
+			statementStart = false
+			switch t.text {
+			case "select", "with", "values", "table", "show", "explain":
+			default:
+				return true
+			}
+		}
+
+		if t.kind != tokenWord && t.kind != tokenIdent {
+			continue
+		}
+
+		switch {
+		case t.kind == tokenWord && isWriteKeyword(t.text):
+			return true
+		case t.kind == tokenWord && t.text == "for" && i+1 < len(tokens) && isLockStrength(tokens[i+1].text):
+			return true
+		case i+1 < len(tokens) && tokens[i+1].text == "(" && a.isPrimaryFunction(t.text):
+			return true
+		}
+	}
+
+	return false
+}
+
+// isWriteKeyword reports whether keyword starts a data-modifying statement or SELECT INTO.
+func isWriteKeyword(keyword string) bool {
+	switch keyword {
+	case "insert", "update", "delete", "merge", "into":
+		return true
+	}
+
+	return false
+}
+
+// isLockStrength reports whether keyword follows FOR in a locking clause.
+func isLockStrength(keyword string) bool {
+	switch keyword {
+	case "update", "share", "no", "key":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/matryer/is"
+)
+
+func TestNeedsPrimary(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{"select", "SELECT id, name FROM users WHERE id = $1", false},
+		{"values", "VALUES (1), (2)", false},
+		{"read-only cte", "WITH u AS (SELECT * FROM users) SELECT * FROM u", false},
+		{"for update", "SELECT * FROM users WHERE id = $1 FOR UPDATE", true},
+		{"for no key update", "select * from users for no key update skip locked", true},
+		{"for share", "SELECT * FROM users FOR SHARE OF users", true},
+		{"for key share", "SELECT * FROM users FOR KEY SHARE", true},
+		{"substring for", "SELECT substring(name FROM 1 FOR 3) FROM users", false},
+		{"insert returning", "INSERT INTO users (name) VALUES ($1) RETURNING id", true},
+		{"modifying cte", "WITH n AS (INSERT INTO users (name) VALUES ($1) RETURNING *) SELECT * FROM n", true},
+		{"delete cte", "WITH d AS (DELETE FROM users RETURNING id) SELECT count(*) FROM d", true},
+		{"select into", "SELECT * INTO users_copy FROM users", true},
+		{"nextval", "SELECT nextval('users_id_seq')", true},
+		{"qualified function", "SELECT pg_catalog.pg_advisory_xact_lock(42)", true},
+		{"function case", "SELECT NextVal('users_id_seq')", true},
+		{"function as column", "SELECT nextval FROM counters", false},
+		{"configured function", "SELECT create_user($1)", true},
+		{"keyword in string", "SELECT * FROM logs WHERE msg = 'FOR UPDATE; DELETE'", false},
+		{"escaped quote in string", "SELECT 'it''s for update' FROM users", false},
+		{"escape string", `SELECT E'\' for update' FROM users`, false},
+		{"keyword in dollar quote", "SELECT $body$ insert into x $body$", false},
+		{"keyword in line comment", "SELECT 1 -- for update\n", false},
+		{"keyword in block comment", "/* delete /* nested */ update */ SELECT 1", false},
+		{"quoted identifier", `SELECT "update", "delete" FROM "insert"`, false},
+		{"leading comment", "-- name: GetUser\nSELECT * FROM users", false},
+		{"parenthesized", "(SELECT 1) UNION (SELECT 2)", false},
+		{"explain", "EXPLAIN SELECT * FROM users", false},
+		{"ddl", "CREATE TABLE t (id int)", true},
+		{"second statement", "SELECT 1; UPDATE users SET name = ''", true},
+		{"call", "CALL refresh_stats()", true},
+	}
+
+	analyzer := newSQLAnalyzer([]string{"Create_User"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(analyzer.needsPrimary(tt.sql), tt.want)
+		})
+	}
+}
+
+func TestSQLConnPicker(t *testing.T) {
+	is := is.New(t)
+
+	primary := &pdb{name: "primary:5432", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+	replica := &pdb{name: "replica:5432", querier: conn.WrapConn(nil, pgxscan.DefaultAPI)}
+	db := &Cluster{pdbs: []*pdb{primary, replica}}
+
+	is.Equal(db.nodeOf(defaultConnPicker(t.Context(), db, "SELECT 1")), replica)
+	is.Equal(db.nodeOf(defaultConnPicker(t.Context(), db, "SELECT 1 FOR UPDATE")), primary)
+	is.Equal(db.nodeOf(defaultConnPicker(t.Context(), db, "SELECT refresh()")), replica)
+
+	picker := SQLConnPicker("refresh")
+	is.Equal(db.nodeOf(picker(t.Context(), db, "SELECT refresh()")), primary)
+	is.Equal(db.nodeOf(picker(t.Context(), db, "SELECT nextval('s')")), primary)
+	is.Equal(db.nodeOf(picker(t.Context(), db, "SELECT 1")), replica)
+}
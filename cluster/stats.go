@@ -0,0 +1,165 @@
+package cluster
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Role of a physical database in the cluster.
+type Role string
+
+const (
+	RolePrimary Role = "primary"
+	RoleReplica Role = "replica"
+)
+
+// PoolStats is a snapshot of pgxpool.Stat counters.
+type PoolStats struct {
+	// AcquiredConns is the number of connections currently in use.
+	AcquiredConns int32
+	// IdleConns is the number of idle connections.
+	IdleConns int32
+	// ConstructingConns is the number of connections being established.
+	ConstructingConns int32
+	// TotalConns is the number of acquired, idle and constructing connections.
+	TotalConns int32
+	// MaxConns is the maximum size of the pool.
+	MaxConns int32
+	// AcquireCount is the number of successful acquires.
+	AcquireCount int64
+	// AcquireDuration is the total time spent by successful acquires.
+	AcquireDuration time.Duration
+	// EmptyAcquireCount is the number of successful acquires that had to wait for a connection.
+	EmptyAcquireCount int64
+	// CanceledAcquireCount is the number of acquires canceled by a context.
+	CanceledAcquireCount int64
+	// NewConnsCount is the number of connections opened.
+	NewConnsCount int64
+	// MaxLifetimeDestroyCount is the number of connections closed for exceeding MaxConnLifetime.
+	MaxLifetimeDestroyCount int64
+	// MaxIdleDestroyCount is the number of connections closed for exceeding MaxConnIdleTime.
+	MaxIdleDestroyCount int64
+}
+
+// NodeStats describes a physical database of the cluster.
+type NodeStats struct {
+	// Name of the node in host:port form.
+	Name string
+	// Host and Port of the node from its DSN.
+	Host string
+	Port uint16
+	// Role of the node.
+	Role Role
+	// Healthy reports whether the node takes part in routing.
+	Healthy bool
+	// Draining reports whether the node is being drained with DrainNode.
+	Draining bool
+	// Lag is the last observed replication lag, -1 if unknown.
+	Lag time.Duration
+	// Latency is the moving average of query durations.
+	Latency time.Duration
+	// Queries is the number of Select, Get, Exec, Query, SendBatch and CopyFrom calls on the node.
+	Queries int64
+	// Transactions is the number of Tx calls on the node.
+	Transactions int64
+	// Errors is the number of failed queries and transactions, canceled ones are not counted.
+	Errors int64
+	// Pool is the connection pool statistics.
+	Pool PoolStats
+}
+
+// ClusterStats describes the cluster.
+type ClusterStats struct {
+	// Nodes in configuration order.
+	Nodes []NodeStats
+	// Healthy is the number of healthy nodes.
+	Healthy int
+	// Queries, Transactions and Errors are summed over all nodes.
+	Queries      int64
+	Transactions int64
+	Errors       int64
+	// Pool is the connection pool statistics summed over all nodes.
+	Pool PoolStats
+}
+
+// Stats returns statistics of each physical database and their sum.
+// Counters of removed nodes are not included.
+func (conn *Cluster) Stats() ClusterStats {
+	pdbs, primary := conn.nodes()
+	stats := ClusterStats{Nodes: make([]NodeStats, len(pdbs))}
+	for i, db := range pdbs {
+		node := db.stats(db == primary)
+		stats.Nodes[i] = node
+
+		if node.Healthy {
+			stats.Healthy++
+		}
+		stats.Queries += node.Queries
+		stats.Transactions += node.Transactions
+		stats.Errors += node.Errors
+		stats.Pool.add(node.Pool)
+	}
+
+	return stats
+}
+
+func (db *pdb) stats(primary bool) NodeStats {
+	config := db.pool.Config().ConnConfig
+	role := RoleReplica
+	if primary {
+		role = RolePrimary
+	}
+
+	lag, ok := db.replicationLag()
+	if !ok {
+		lag = lagUnknown
+	}
+
+	return NodeStats{
+		Name:         db.name,
+		Host:         config.Host,
+		Port:         config.Port,
+		Role:         role,
+		Healthy:      db.healthy(),
+		Draining:     db.draining.Load(),
+		Lag:          lag,
+		Latency:      db.Latency(),
+		Queries:      db.queries.Load(),
+		Transactions: db.transactions.Load(),
+		Errors:       db.errors.Load(),
+		Pool:         newPoolStats(db.pool.Stat()),
+	}
+}
+
+func newPoolStats(s *pgxpool.Stat) PoolStats {
+	return PoolStats{
+		AcquiredConns:           s.AcquiredConns(),
+		IdleConns:               s.IdleConns(),
+		ConstructingConns:       s.ConstructingConns(),
+		TotalConns:              s.TotalConns(),
+		MaxConns:                s.MaxConns(),
+		AcquireCount:            s.AcquireCount(),
+		AcquireDuration:         s.AcquireDuration(),
+		EmptyAcquireCount:       s.EmptyAcquireCount(),
+		CanceledAcquireCount:    s.CanceledAcquireCount(),
+		NewConnsCount:           s.NewConnsCount(),
+		MaxLifetimeDestroyCount: s.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     s.MaxIdleDestroyCount(),
+	}
+}
+
+func (s *PoolStats) add(o PoolStats) {
+	s.AcquiredConns += o.AcquiredConns
+	s.IdleConns += o.IdleConns
+	s.ConstructingConns += o.ConstructingConns
+	s.TotalConns += o.TotalConns
+	s.MaxConns += o.MaxConns
+	s.AcquireCount += o.AcquireCount
+	s.AcquireDuration += o.AcquireDuration
+	s.EmptyAcquireCount += o.EmptyAcquireCount
+	s.CanceledAcquireCount += o.CanceledAcquireCount
+	s.NewConnsCount += o.NewConnsCount
+	s.MaxLifetimeDestroyCount += o.MaxLifetimeDestroyCount
+	s.MaxIdleDestroyCount += o.MaxIdleDestroyCount
+}
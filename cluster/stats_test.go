@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/matryer/is"
+)
+
+func TestStats(t *testing.T) {
+	is := is.New(t)
+
+	db, err := Open([]string{
+		"host=127.0.0.1 port=1 pool_max_conns=2 connect_timeout=1",
+		"host=127.0.0.1 port=2 pool_max_conns=3 connect_timeout=1",
+	})
+	is.NoErr(err)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	_, err = db.Primary().Exec(ctx, "SELECT 1")
+	is.True(err != nil) // primary is unreachable
+
+	canceled, cancelNow := context.WithCancel(ctx)
+	cancelNow()
+	var n int
+	is.True(db.Primary().Get(canceled, &n, "SELECT 1") != nil)
+
+	err = db.Primary().Tx(ctx, func(conn.Querier) error { return nil })
+	is.True(err != nil)
+
+	db.pdbs[1].unhealthy.Store(true)
+	db.pdbs[1].lag.Store(int64(time.Second))
+
+	stats := db.Stats()
+	is.Equal(len(stats.Nodes), 2)
+
+	primary := stats.Nodes[0]
+	is.Equal(primary.Name, "127.0.0.1:1")
+	is.Equal(primary.Host, "127.0.0.1")
+	is.Equal(primary.Port, uint16(1))
+	is.Equal(primary.Role, RolePrimary)
+	is.True(primary.Healthy)
+	is.Equal(primary.Queries, int64(2))
+	is.Equal(primary.Transactions, int64(1))
+	is.Equal(primary.Errors, int64(2)) // canceled Get is not an error
+	is.Equal(primary.Pool.MaxConns, int32(2))
+
+	replica := stats.Nodes[1]
+	is.Equal(replica.Role, RoleReplica)
+	is.True(!replica.Healthy)
+	is.Equal(replica.Lag, time.Second)
+	is.Equal(replica.Queries, int64(0))
+
+	is.Equal(stats.Healthy, 1)
+	is.Equal(stats.Queries, int64(2))
+	is.Equal(stats.Transactions, int64(1))
+	is.Equal(stats.Errors, int64(2))
+	is.Equal(stats.Pool.MaxConns, int32(5))
+}
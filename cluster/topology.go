@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// drainPollInterval is the delay between in-flight work checks of a draining node.
+const drainPollInterval = 10 * time.Millisecond
+
+var (
+	// ErrNodeExists is returned by AddNode when a node with the same name is already in the cluster.
+	ErrNodeExists = errors.New("node already exists")
+	// ErrPrimaryNode is returned when the primary is asked to leave the cluster.
+	ErrPrimaryNode = errors.New("node is the primary")
+	// ErrNodeRemoved is returned for a call routed to a node that left the cluster before the call started.
+	// IsConnectionError reports true for it, so reads are retried on another node.
+	ErrNodeRemoved = errors.New("node removed from the cluster")
+)
+
+// AddNode opens a physical database and adds it to the cluster as a replica.
+// The node takes part in routing once it answers a ping and, if replication lag is monitored,
+// once its lag is known to be within the bound.
+func (conn *Cluster) AddNode(ctx context.Context, config *pgxpool.Config, opts ...NodeOption) error {
+	name := nodeName(config)
+	if pdbs, _ := conn.nodes(); slices.ContainsFunc(pdbs, func(db *pdb) bool { return db.name == name }) {
+		return fmt.Errorf("%w: %s", ErrNodeExists, name)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return fmt.Errorf("failed to ping node: %w", err)
+	}
+
+	db := newPDB(conn, pool, defaultWeight)
+	for _, o := range opts {
+		o(db)
+	}
+
+	if conn.maxLag > 0 {
+		lagCtx, cancel := context.WithTimeout(ctx, conn.lagCheck)
+		_ = db.queryLag(lagCtx)
+		cancel()
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if slices.ContainsFunc(conn.pdbs, func(p *pdb) bool { return p.name == name }) {
+		pool.Close()
+		return fmt.Errorf("%w: %s", ErrNodeExists, name)
+	}
+
+	conn.pdbs = append(slices.Clip(conn.pdbs), db)
+	return nil
+}
+
+// RemoveNode removes the node with name in host:port form from the cluster and closes its pool.
+// Queries running on the node are not waited for, see DrainNode.
+func (conn *Cluster) RemoveNode(name string) error {
+	db, err := conn.detach(name)
+	if err != nil {
+		return err
+	}
+
+	db.removed.Store(true)
+	db.pool.Close()
+	return nil
+}
+
+// DrainNode stops routing new queries to the node with name in host:port form,
+// waits for in-flight queries and transactions to finish, then removes the node and closes its pool.
+// Calls routed to the node that have not started yet fail with ErrNodeRemoved,
+// Replica queriers obtained earlier switch to another replica.
+// If ctx is done first the node keeps draining and ctx error is returned,
+// the node can be removed at once with RemoveNode.
+func (conn *Cluster) DrainNode(ctx context.Context, name string) error {
+	db, err := conn.node(name)
+	if err != nil {
+		return err
+	}
+
+	db.draining.Store(true)
+	db.removed.Store(true)
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for db.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return conn.RemoveNode(name)
+}
+
+// node returns a replica by name.
+func (conn *Cluster) node(name string) (*pdb, error) {
+	pdbs, primary := conn.nodes()
+	for _, db := range pdbs {
+		if db.name != name {
+			continue
+		}
+		if db == primary {
+			return nil, fmt.Errorf("%w: %s", ErrPrimaryNode, name)
+		}
+
+		return db, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownNode, name)
+}
+
+// detach removes a replica from the topology and returns it.
+func (conn *Cluster) detach(name string) (*pdb, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	i := slices.IndexFunc(conn.pdbs, func(db *pdb) bool { return db.name == name })
+	switch {
+	case i < 0:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNode, name)
+	case i == conn.primaryIdx:
+		return nil, fmt.Errorf("%w: %s", ErrPrimaryNode, name)
+	case i < conn.primaryIdx:
+		conn.primaryIdx--
+	}
+
+	db := conn.pdbs[i]
+	conn.pdbs = slices.Delete(slices.Clone(conn.pdbs), i, i+1)
+
+	return db, nil
+}
+
+// setPrimary makes db the primary, it reports false if db is no longer in the cluster.
+func (conn *Cluster) setPrimary(db *pdb) bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	i := slices.Index(conn.pdbs, db)
+	if i < 0 {
+		return false
+	}
+
+	conn.primaryIdx = i
+	return true
+}
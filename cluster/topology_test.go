@@ -0,0 +1,180 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgmock"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matryer/is"
+)
+
+func TestTopology(t *testing.T) {
+	newCluster := func(t *testing.T) *Cluster {
+		db, err := Open([]string{"host=127.0.0.1 port=1", "host=127.0.0.1 port=2", "host=127.0.0.1 port=3"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		return db
+	}
+
+	names := func(db *Cluster) []string {
+		var names []string
+		for _, h := range db.Health() {
+			names = append(names, h.Name)
+		}
+		return names
+	}
+
+	t.Run("add node", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		dsn := testDatabase(t,
+			pgmock.ExpectMessage(&pgproto3.Query{String: "-- ping"}),
+			pgmock.SendMessage(&pgproto3.EmptyQueryResponse{}),
+			pgmock.SendMessage(&pgproto3.ReadyForQuery{TxStatus: 'I'}),
+			pgmock.ExpectMessage(&pgproto3.Terminate{}),
+		)
+		cfg, err := pgxpool.ParseConfig(dsn)
+		is.NoErr(err)
+
+		err = db.AddNode(t.Context(), cfg, NodeWeight(5))
+		is.NoErr(err)
+		is.Equal(len(names(db)), 4)
+
+		added, err := db.node(nodeName(cfg))
+		is.NoErr(err)
+		is.Equal(added.Weight(), 5)
+	})
+
+	t.Run("add existing node", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		cfg, err := pgxpool.ParseConfig("host=127.0.0.1 port=2")
+		is.NoErr(err)
+
+		is.True(errors.Is(db.AddNode(t.Context(), cfg), ErrNodeExists))
+	})
+
+	t.Run("add unreachable node", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		cfg, err := pgxpool.ParseConfig("host=127.0.0.1 port=4")
+		is.NoErr(err)
+
+		is.True(db.AddNode(t.Context(), cfg) != nil)
+		is.Equal(len(names(db)), 3)
+	})
+
+	t.Run("remove node", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		is.True(errors.Is(db.RemoveNode("127.0.0.1:1"), ErrPrimaryNode))
+		is.True(errors.Is(db.RemoveNode("127.0.0.1:9"), ErrUnknownNode))
+
+		is.NoErr(db.RemoveNode("127.0.0.1:2"))
+		is.Equal(names(db), []string{"127.0.0.1:1", "127.0.0.1:3"})
+	})
+
+	t.Run("primary is kept when preceding node is removed", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		primary := db.pdbs[1]
+		is.True(db.setPrimary(primary))
+
+		is.NoErr(db.RemoveNode("127.0.0.1:1"))
+		is.Equal(db.primary(), primary)
+	})
+
+	t.Run("drain node", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		node, err := db.node("127.0.0.1:2")
+		is.NoErr(err)
+		node.inflight.Add(1)
+
+		done := make(chan error)
+		go func() { done <- db.DrainNode(t.Context(), "127.0.0.1:2") }()
+
+		deadline := time.Now().Add(time.Second)
+		for !node.draining.Load() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		for range 10 {
+			is.True(db.pickReplica() != node) // draining node gets no new queries
+		}
+		is.Equal(len(names(db)), 3) // node is kept until in-flight work is done
+
+		node.inflight.Add(-1)
+		is.NoErr(<-done)
+		is.Equal(names(db), []string{"127.0.0.1:1", "127.0.0.1:3"})
+	})
+
+	t.Run("drain canceled", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		node, err := db.node("127.0.0.1:2")
+		is.NoErr(err)
+		node.inflight.Add(1)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+
+		is.True(errors.Is(db.DrainNode(ctx, "127.0.0.1:2"), context.DeadlineExceeded))
+		is.True(node.draining.Load())
+		is.Equal(len(names(db)), 3)
+	})
+
+	t.Run("calls routed before drain", func(t *testing.T) {
+		is := is.New(t)
+
+		db := newCluster(t)
+		node, err := db.node("127.0.0.1:2")
+		is.NoErr(err)
+		replica := &replicaQuerier{cluster: db, db: node} // Replica() picked the node before the drain
+
+		is.NoErr(db.DrainNode(t.Context(), "127.0.0.1:2"))
+
+		_, err = node.querier.Exec(t.Context(), "DELETE FROM users")
+		is.True(errors.Is(err, ErrNodeRemoved)) // the pool is not used after close
+		is.True(IsConnectionError(err))
+		is.Equal(node.inflight.Load(), int64(0))
+
+		target, err := replica.target(t.Context())
+		is.NoErr(err)
+		is.True(target != node)
+	})
+
+	t.Run("concurrent routing", func(t *testing.T) {
+		db := newCluster(t)
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 1000 {
+					_ = db.Replica()
+					_ = db.Primary()
+				}
+			}()
+		}
+
+		_ = db.RemoveNode("127.0.0.1:2")
+		_ = db.RemoveNode("127.0.0.1:3")
+		wg.Wait()
+	})
+}
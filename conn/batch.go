@@ -0,0 +1,112 @@
+package conn
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Batch queues statements sent to the database in one round trip by Querier.SendBatch.
+// Each statement declares how its result is read, like Get, Select and Exec of Querier.
+type Batch struct {
+	items []BatchItem
+}
+
+// BatchItem is a statement queued in a batch.
+type BatchItem struct {
+	// Method that reads the result: MethodGet, MethodSelect or MethodExec.
+	Method Method
+	SQL    string
+	Args   []any
+
+	dst          any
+	rowsAffected *int64
+}
+
+// Get queues a statement whose only row is scanned into dst, see Querier.Get.
+func (b *Batch) Get(dst any, sql string, args ...any) *Batch {
+	b.items = append(b.items, BatchItem{Method: MethodGet, SQL: sql, Args: args, dst: dst})
+	return b
+}
+
+// Select queues a statement whose rows are scanned into dst slice, see Querier.Select.
+func (b *Batch) Select(dst any, sql string, args ...any) *Batch {
+	b.items = append(b.items, BatchItem{Method: MethodSelect, SQL: sql, Args: args, dst: dst})
+	return b
+}
+
+// Exec queues a statement without result rows, the number of affected rows is stored into rowsAffected unless it is nil.
+func (b *Batch) Exec(rowsAffected *int64, sql string, args ...any) *Batch {
+	b.items = append(b.items, BatchItem{Method: MethodExec, SQL: sql, Args: args, rowsAffected: rowsAffected})
+	return b
+}
+
+// Len returns the number of queued statements.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// Items returns the queued statements, the slice must not be modified.
+func (b *Batch) Items() []BatchItem {
+	return b.items
+}
+
+// ReadOnly reports whether the batch has only Get and Select statements.
+func (b *Batch) ReadOnly() bool {
+	for _, item := range b.items {
+		if item.Method == MethodExec {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sendBatch sends b and reads results of its statements in order.
+// It stops at the first failed statement.
+func (n *wrappedConn) sendBatch(ctx context.Context, b *Batch) error {
+	batch := &pgx.Batch{}
+	for _, item := range b.items {
+		batch.Queue(item.SQL, item.Args...)
+	}
+
+	results := n.Conn(ctx).SendBatch(ctx, batch)
+	for i, item := range b.items {
+		if err := n.readBatchResult(results, item); err != nil {
+			_ = results.Close()
+			return fmt.Errorf("batch statement %d: %w", i, err)
+		}
+	}
+
+	return results.Close()
+}
+
+// readBatchResult reads the next result of the batch into the destination of item.
+func (n *wrappedConn) readBatchResult(results pgx.BatchResults, item BatchItem) error {
+	if item.Method == MethodExec {
+		tag, err := results.Exec()
+		if err != nil {
+			return err
+		}
+
+		if item.rowsAffected != nil {
+			*item.rowsAffected = tag.RowsAffected()
+		}
+		return nil
+	}
+
+	rows, err := results.Query()
+	if err != nil {
+		if rows != nil {
+			rows.Close()
+		}
+		return err
+	}
+
+	if item.Method == MethodGet {
+		return n.scanAPI.ScanOne(item.dst, rows)
+	}
+
+	return n.scanAPI.ScanAll(item.dst, rows)
+}
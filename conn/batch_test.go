@@ -0,0 +1,70 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/matryer/is"
+)
+
+func TestBatch(t *testing.T) {
+	t.Run("ReadOnly", func(t *testing.T) {
+		is := is.New(t)
+
+		var n int
+		b := (&Batch{}).Get(&n, `SELECT 1`).Select(&[]int{}, `SELECT 2`)
+		is.Equal(b.Len(), 2)
+		is.True(b.ReadOnly())
+
+		b.Exec(nil, `DELETE FROM users`)
+		is.Equal(b.Len(), 3)
+		is.True(!b.ReadOnly())
+		is.Equal(b.Items()[2].Method, MethodExec)
+	})
+
+	t.Run("results", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			_, err := querier.Exec(ctx, `CREATE TEMP TABLE batch_test (id int)`)
+			its.NoErr(err)
+
+			var (
+				inserted int64
+				count    int
+				ids      []int
+			)
+			b := &Batch{}
+			b.Exec(&inserted, `INSERT INTO batch_test SELECT generate_series(1, $1::int)`, 3)
+			b.Get(&count, `SELECT count(*) FROM batch_test`)
+			b.Select(&ids, `SELECT id FROM batch_test ORDER BY id`)
+
+			its.NoErr(querier.SendBatch(ctx, b))
+			its.Equal(inserted, int64(3))
+			its.Equal(count, 3)
+			its.Equal(ids, []int{1, 2, 3})
+		})
+	})
+
+	t.Run("failed statement", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			var n int
+			b := (&Batch{}).Get(&n, `SELECT 1`).Exec(nil, `SELECT 1/0`)
+
+			err := querier.SendBatch(ctx, b)
+			var pgErr *pgconn.PgError
+			its.True(errors.As(err, &pgErr))
+			its.Equal(pgErr.Code, "22012") // division_by_zero
+			its.True(strings.HasPrefix(err.Error(), "batch statement 1:"))
+		})
+	})
+}
@@ -0,0 +1,183 @@
+package conn
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/georgysavva/scany/v2/dbscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrNotStructSlice is returned by CopyFrom when rows is not a slice of structs or pointers to structs.
+	ErrNotStructSlice = errors.New("rows must be a slice of structs")
+	// ErrNestedStruct is returned by CopyFrom when rows have nested struct fields that map to several columns.
+	ErrNestedStruct = errors.New("nested structs are not supported")
+)
+
+// copyColumn is a struct field copied into a table column.
+type copyColumn struct {
+	name  string
+	index []int
+}
+
+// structColumns returns columns of exported fields of t named like pgxscan.DefaultAPI does:
+// by the db tag or the snake cased field name, fields tagged with "-" are skipped.
+// Embedded structs without a db tag contribute their fields, any other field is a single column.
+// Structs scany would expand into prefixed columns, embedded ones with a db tag and nested ones
+// that are not sql.Scanner or driver.Valuer, fail with ErrNestedStruct.
+func structColumns(t reflect.Type, prefix []int) ([]copyColumn, error) {
+	var columns []copyColumn
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag, tagged := field.Tag.Lookup("db")
+		tag, _, _ = strings.Cut(tag, ",")
+		if tag == "-" {
+			continue
+		}
+
+		index := append(append([]int(nil), prefix...), i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			if tagged {
+				return nil, fmt.Errorf("%w: embedded %s has db tag", ErrNestedStruct, field.Name)
+			}
+
+			embedded, err := structColumns(fieldType, index)
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, embedded...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if nestedStruct(fieldType) {
+			return nil, fmt.Errorf("%w: field %s", ErrNestedStruct, field.Name)
+		}
+
+		name := tag
+		if !tagged {
+			name = dbscan.SnakeCaseMapper(field.Name)
+		}
+		columns = append(columns, copyColumn{name: name, index: index})
+	}
+
+	return columns, nil
+}
+
+// nestedStruct reports whether t is a struct with exported fields scany maps to columns of their own.
+// Structs like time.Time without exported fields and database types are copied as a single value.
+func nestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	scanner, valuer := reflect.TypeFor[sql.Scanner](), reflect.TypeFor[driver.Valuer]()
+	if ptr := reflect.PointerTo(t); ptr.Implements(scanner) || ptr.Implements(valuer) {
+		return false
+	}
+
+	for i := range t.NumField() {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// structRows is pgx.CopyFromSource over a slice of structs.
+type structRows struct {
+	rows    reflect.Value
+	columns []copyColumn
+	next    int
+	values  []any
+}
+
+// newStructRows returns the copy source for rows, a slice of structs or pointers to structs, and names of its columns.
+func newStructRows(rows any) (*structRows, []string, error) {
+	v := reflect.ValueOf(rows)
+	if v.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("%w, got %T", ErrNotStructSlice, rows)
+	}
+
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("%w, got %T", ErrNotStructSlice, rows)
+	}
+
+	columns, err := structColumns(elem, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, len(columns))
+	for i := range columns {
+		names[i] = columns[i].name
+	}
+
+	return &structRows{rows: v, columns: columns, values: make([]any, len(columns))}, names, nil
+}
+
+func (r *structRows) Next() bool {
+	r.next++
+	return r.next <= r.rows.Len()
+}
+
+func (r *structRows) Values() ([]any, error) {
+	row := reflect.Indirect(r.rows.Index(r.next - 1))
+	if !row.IsValid() {
+		return nil, fmt.Errorf("row %d is nil", r.next-1)
+	}
+
+	for i, c := range r.columns {
+		r.values[i] = fieldValue(row, c.index)
+	}
+
+	return r.values, nil
+}
+
+func (r *structRows) Err() error {
+	return nil
+}
+
+// fieldValue returns the field at index of v, fields of a nil embedded struct are nil.
+func fieldValue(v reflect.Value, index []int) any {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v.Interface()
+}
+
+// copyFrom copies rows into table with the COPY protocol.
+func (n *wrappedConn) copyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error) {
+	src, columns, err := newStructRows(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	return n.Conn(ctx).CopyFrom(ctx, table, columns, src)
+}
@@ -0,0 +1,121 @@
+package conn
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/matryer/is"
+)
+
+type copyBase struct {
+	ID int64
+}
+
+type copyUser struct {
+	copyBase
+	FullName  string
+	Email     *string `db:"mail"`
+	CreatedAt time.Time
+	Ignored   string `db:"-"`
+	private   string //nolint: unused // Unexported fields are not copied.
+}
+
+func TestStructColumns(t *testing.T) {
+	is := is.New(t)
+
+	rows, columns, err := newStructRows([]*copyUser{{copyBase: copyBase{ID: 1}, FullName: "one"}})
+	is.NoErr(err)
+	is.Equal(columns, []string{"id", "full_name", "mail", "created_at"})
+
+	is.True(rows.Next())
+	values, err := rows.Values()
+	is.NoErr(err)
+	is.Equal(values, []any{int64(1), "one", (*string)(nil), time.Time{}})
+	is.True(!rows.Next())
+
+	_, _, err = newStructRows([]int{1})
+	is.True(errors.Is(err, ErrNotStructSlice))
+
+	_, _, err = newStructRows(copyUser{})
+	is.True(errors.Is(err, ErrNotStructSlice))
+
+	type withPointer struct {
+		*copyBase
+		Name string
+	}
+	rows, columns, err = newStructRows([]withPointer{{Name: "nil base"}})
+	is.NoErr(err)
+	is.Equal(columns, []string{"id", "name"})
+	is.True(rows.Next())
+	values, err = rows.Values()
+	is.NoErr(err)
+	is.True(reflect.DeepEqual(values, []any{nil, "nil base"}))
+
+	type withTaggedBase struct {
+		copyBase `db:"base"`
+		Name     string
+	}
+	_, _, err = newStructRows([]withTaggedBase{})
+	is.True(errors.Is(err, ErrNestedStruct)) // scany prefixes columns of a tagged embedded struct
+
+	type withNested struct {
+		Name string
+		Base copyBase
+	}
+	_, _, err = newStructRows([]withNested{})
+	is.True(errors.Is(err, ErrNestedStruct)) // scany expands a nested struct into base.id
+
+	type withValues struct {
+		Name    sql.NullString
+		Balance pgtype.Numeric
+	}
+	_, columns, err = newStructRows([]withValues{})
+	is.NoErr(err) // database types are single columns
+	is.Equal(columns, []string{"name", "balance"})
+}
+
+func TestCopyFrom(t *testing.T) {
+	TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+		its := is.New(t)
+		querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+		_, err := querier.Exec(ctx, `CREATE TEMP TABLE copy_users (id bigint, full_name text, mail text, created_at timestamptz)`)
+		its.NoErr(err)
+
+		mail := "two@example.com"
+		users := []copyUser{
+			{copyBase: copyBase{ID: 1}, FullName: "one", CreatedAt: time.Now()},
+			{copyBase: copyBase{ID: 2}, FullName: "two", Email: &mail, CreatedAt: time.Now()},
+		}
+
+		err = querier.Tx(ctx, func(q Querier) error {
+			copied, err := q.CopyFrom(ctx, pgx.Identifier{"copy_users"}, users)
+			its.NoErr(err)
+			its.Equal(copied, int64(2))
+
+			return errors.New("rollback")
+		})
+		its.True(err != nil)
+
+		var count int
+		its.NoErr(querier.Get(ctx, &count, `SELECT count(*) FROM copy_users`))
+		its.Equal(count, 0) // copy was rolled back with the transaction
+
+		copied, err := querier.CopyFrom(ctx, pgx.Identifier{"copy_users"}, users)
+		its.NoErr(err)
+		its.Equal(copied, int64(2))
+
+		var got []copyUser
+		its.NoErr(querier.Select(ctx, &got, `SELECT id, full_name, mail FROM copy_users ORDER BY id`))
+		its.Equal(len(got), 2)
+		its.Equal(got[1].FullName, "two")
+		its.Equal(*got[1].Email, mail)
+	})
+}
@@ -0,0 +1,72 @@
+package conn
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+)
+
+// Transactor runs functions in transactions, Querier and cluster.Conn implement it.
+type Transactor interface {
+	Tx(ctx context.Context, f func(q Querier) error, opts ...TxOption) error
+}
+
+// Cursor declares a server-side cursor for sql in a transaction started with q
+// and fetches rows in chunks of chunkSize scanned into a []T as the sequence is ranged over.
+// The cursor is closed and the transaction committed when the loop ends, including early break.
+// An error stops the sequence: it is yielded with nil chunk as the last pair
+// after the cursor is closed, even if ctx is canceled.
+// To read from a replica pass cluster.Conn.Replica as q.
+// The loop body runs inside q.Tx, so it must not call q when q serializes its calls,
+// e.g. a txdb connection holds its lock for the whole Tx and such a call deadlocks.
+func Cursor[T any](ctx context.Context, q Transactor, chunkSize int, sql string, args ...any) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		if chunkSize <= 0 {
+			yield(nil, fmt.Errorf("invalid cursor chunk size %d", chunkSize))
+			return
+		}
+
+		stopped := false
+		err := q.Tx(ctx, func(tx Querier) error {
+			name := fmt.Sprintf("pgxext_cursor_%x", rand.Uint64()) //nolint: gosec // Name only has to be unique in the transaction.
+			if _, err := tx.Exec(ctx, "DECLARE "+name+" NO SCROLL CURSOR FOR "+sql, args...); err != nil {
+				return fmt.Errorf("declare cursor: %w", err)
+			}
+
+			err := fetchChunks(ctx, tx, name, chunkSize, func(chunk []T) bool {
+				if !yield(chunk, nil) {
+					stopped = true
+				}
+				return !stopped
+			})
+
+			// the cursor is closed with the transaction anyway, closing it here releases it early
+			// when the transaction is not the outermost one.
+			if _, closeErr := tx.Exec(context.WithoutCancel(ctx), "CLOSE "+name); closeErr != nil && err == nil {
+				err = fmt.Errorf("close cursor: %w", closeErr)
+			}
+
+			return err
+		})
+
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
+
+// fetchChunks fetches rows from cursor name until it is exhausted or f returns false.
+func fetchChunks[T any](ctx context.Context, tx Querier, name string, chunkSize int, f func(chunk []T) bool) error {
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", chunkSize, name)
+	for {
+		var chunk []T
+		if err := tx.Select(ctx, &chunk, fetch); err != nil {
+			return fmt.Errorf("fetch cursor: %w", err)
+		}
+
+		if len(chunk) == 0 || !f(chunk) || len(chunk) < chunkSize {
+			return nil
+		}
+	}
+}
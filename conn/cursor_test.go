@@ -0,0 +1,67 @@
+package conn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/matryer/is"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("chunks", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			var chunks [][]int
+			for chunk, err := range Cursor[int](ctx, querier, 2, `SELECT generate_series(1, $1::int)`, 5) {
+				its.NoErr(err)
+				chunks = append(chunks, chunk)
+			}
+			its.Equal(chunks, [][]int{{1, 2}, {3, 4}, {5}})
+		})
+	})
+
+	t.Run("early break closes cursor", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			err := querier.Tx(ctx, func(q Querier) error {
+				for chunk, err := range Cursor[int](ctx, q, 10, `SELECT generate_series(1, 100)`) {
+					its.NoErr(err)
+					its.Equal(len(chunk), 10)
+					break
+				}
+
+				var open int
+				if err := q.Get(ctx, &open, `SELECT count(*) FROM pg_cursors`); err != nil {
+					return err
+				}
+				its.Equal(open, 0) // cursor is closed in the outer transaction
+				return nil
+			})
+			its.NoErr(err)
+		})
+	})
+
+	t.Run("error", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			var chunks, errs int
+			for _, err := range Cursor[int](ctx, querier, 10, `SELECT 1/(3 - generate_series(1, 5))`) {
+				if err != nil {
+					errs++
+					continue
+				}
+				chunks++
+			}
+			its.Equal(chunks, 0) // division by zero fails the first fetch
+			its.Equal(errs, 1)
+		})
+	})
+}
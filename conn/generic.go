@@ -0,0 +1,52 @@
+package conn
+
+import "context"
+
+// Getter retrieves one row, Querier and cluster.Conn implement it.
+type Getter interface {
+	Get(ctx context.Context, dst any, sql string, args ...any) error
+}
+
+// Selector retrieves multiple rows, Querier and cluster.Conn implement it.
+type Selector interface {
+	Select(ctx context.Context, dst any, sql string, args ...any) error
+}
+
+// GetOne retrieves one row and scans it into a T.
+// See Querier.Get for details.
+func GetOne[T any](ctx context.Context, q Getter, sql string, args ...any) (T, error) {
+	var dst T
+	if err := q.Get(ctx, &dst, sql, args...); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return dst, nil
+}
+
+// SelectAll retrieves multiple rows and scans each into a T.
+// See Querier.Select for details.
+func SelectAll[T any](ctx context.Context, q Selector, sql string, args ...any) ([]T, error) {
+	var dst []T
+	if err := q.Select(ctx, &dst, sql, args...); err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// SelectMap retrieves multiple rows, scans each into a V and indexes them by key.
+// If several rows have the same key the last one is kept.
+func SelectMap[K comparable, V any](ctx context.Context, q Selector, key func(V) K, sql string, args ...any) (map[K]V, error) {
+	rows, err := SelectAll[V](ctx, q, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[K]V, len(rows))
+	for _, row := range rows {
+		m[key(row)] = row
+	}
+
+	return m, nil
+}
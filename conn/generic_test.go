@@ -0,0 +1,58 @@
+package conn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/matryer/is"
+)
+
+func TestGeneric(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	t.Run("GetOne", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			u, err := GetOne[user](ctx, querier, `SELECT 1 AS id, 'one' AS name`)
+			its.NoErr(err)
+			its.Equal(u, user{ID: 1, Name: "one"})
+
+			n, err := GetOne[int](ctx, querier, `SELECT $1::int`, 42)
+			its.NoErr(err)
+			its.Equal(n, 42)
+
+			_, err = GetOne[user](ctx, querier, `SELECT 1 AS id, 'one' AS name WHERE false`)
+			its.True(pgxscan.NotFound(err))
+		})
+	})
+
+	t.Run("SelectAll", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			users, err := SelectAll[*user](ctx, querier, `SELECT * FROM (VALUES (1, 'one'), (2, 'two')) AS t(id, name)`)
+			its.NoErr(err)
+			its.Equal(users, []*user{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}})
+		})
+	})
+
+	t.Run("SelectMap", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			users, err := SelectMap(ctx, querier, func(u user) int { return u.ID },
+				`SELECT * FROM (VALUES (1, 'one'), (2, 'two'), (2, 'second two')) AS t(id, name)`)
+			its.NoErr(err)
+			its.Equal(users, map[int]user{1: {ID: 1, Name: "one"}, 2: {ID: 2, Name: "second two"}})
+		})
+	})
+}
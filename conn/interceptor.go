@@ -0,0 +1,80 @@
+package conn
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Method is the Querier method being called.
+type Method string
+
+const (
+	MethodSelect Method = "Select"
+	MethodGet    Method = "Get"
+	MethodExec   Method = "Exec"
+	MethodQuery  Method = "Query"
+	MethodBatch  Method = "Batch"
+	MethodCopy   Method = "Copy"
+	MethodTx     Method = "Tx"
+)
+
+// Call describes a Querier call passed through interceptors.
+// Interceptors may change SQL and Args before calling the next handler.
+type Call struct {
+	// Method being called.
+	Method Method
+	// SQL and Args of the query, empty for Tx, SendBatch and CopyFrom.
+	SQL  string
+	Args []any
+	// Dst is the scan destination of Select and Get.
+	Dst any
+	// RowsAffected is set by Exec and CopyFrom once the query succeeds.
+	RowsAffected int64
+	// Rows are set by Query once the query is sent.
+	Rows pgx.Rows
+	// Batch sent by SendBatch.
+	Batch *Batch
+	// Table and Source of CopyFrom, Source is a slice of structs.
+	Table  pgx.Identifier
+	Source any
+	// TxErr is set by Tx when the transaction fails to begin, apply options or commit,
+	// errors returned by the callback are not set.
+	TxErr error
+}
+
+// Handler executes the call.
+type Handler func(ctx context.Context, call *Call) error
+
+// Interceptor runs around Select, Get, Exec, Query, SendBatch, CopyFrom and Tx calls.
+// It calls next to continue the chain, or returns without calling it to block the call.
+type Interceptor func(ctx context.Context, call *Call, next Handler) error
+
+// Options for wrapped connection.
+type Options struct {
+	// Interceptors run in order, the first one is the outermost.
+	Interceptors []Interceptor
+}
+
+// Option func.
+type Option func(*Options)
+
+// WithInterceptors appends interceptors to the chain.
+// Queriers passed to Tx callbacks inherit the chain.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(o *Options) {
+		o.Interceptors = append(o.Interceptors, interceptors...)
+	}
+}
+
+// chain returns handler h wrapped by interceptors.
+func chain(interceptors []Interceptor, h Handler) Handler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], h
+		h = func(ctx context.Context, call *Call) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+
+	return h
+}
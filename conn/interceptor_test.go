@@ -0,0 +1,100 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/matryer/is"
+)
+
+func TestInterceptors(t *testing.T) {
+	t.Run("Tx error", func(t *testing.T) {
+		is := is.New(t)
+
+		var txErrs []error
+		record := func(ctx context.Context, call *Call, next Handler) error {
+			err := next(ctx, call)
+			txErrs = append(txErrs, call.TxErr)
+			return err
+		}
+
+		commitErr := errors.New("commit failed")
+		querier := WrapConn(&stubTx{commitErr: commitErr}, pgxscan.DefaultAPI, WithInterceptors(record))
+		is.Equal(querier.Tx(t.Context(), func(Querier) error { return nil }), commitErr)
+
+		fErr := errors.New("callback failed")
+		is.Equal(querier.Tx(t.Context(), func(Querier) error { return fErr }), fErr)
+		is.Equal(txErrs, []error{commitErr, nil}) // only errors outside the callback are set
+	})
+
+	t.Run("chain order and blocking", func(t *testing.T) {
+		its := is.New(t)
+
+		var calls []string
+		record := func(name string) Interceptor {
+			return func(ctx context.Context, call *Call, next Handler) error {
+				calls = append(calls, name+" "+string(call.Method)+" "+call.SQL)
+				return next(ctx, call)
+			}
+		}
+
+		errBlocked := errors.New("blocked")
+		block := func(_ context.Context, call *Call, _ Handler) error {
+			calls = append(calls, "block "+string(call.Method))
+			return errBlocked
+		}
+
+		querier := WrapConn(nil, pgxscan.DefaultAPI, WithInterceptors(record("first"), record("second")), WithInterceptors(block))
+
+		var value int
+		its.Equal(querier.Get(t.Context(), &value, "SELECT 1"), errBlocked)
+		rows, err := querier.Exec(t.Context(), "DELETE FROM users")
+		its.Equal(err, errBlocked)
+		its.Equal(rows, int64(0))
+		its.Equal(querier.Tx(t.Context(), func(Querier) error { return nil }), errBlocked)
+
+		its.Equal(calls, []string{
+			"first Get SELECT 1", "second Get SELECT 1", "block Get",
+			"first Exec DELETE FROM users", "second Exec DELETE FROM users", "block Exec",
+			"first Tx ", "second Tx ", "block Tx",
+		})
+	})
+
+	t.Run("rewrite and inherit in Tx", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+
+			var methods []Method
+			interceptor := func(ctx context.Context, call *Call, next Handler) error {
+				methods = append(methods, call.Method)
+				if call.SQL == "SELECT 'original'" {
+					call.SQL = "SELECT $1::text"
+					call.Args = []any{"rewritten"}
+				}
+
+				err := next(ctx, call)
+				if call.Method == MethodExec {
+					its.Equal(call.RowsAffected, int64(2))
+				}
+				return err
+			}
+
+			querier := WrapConn(conn, pgxscan.DefaultAPI, WithInterceptors(interceptor))
+			err := querier.Tx(ctx, func(q Querier) error {
+				var value string
+				if err := q.Get(ctx, &value, "SELECT 'original'"); err != nil {
+					return err
+				}
+				its.Equal(value, "rewritten")
+
+				_, err := q.Exec(ctx, "SELECT * FROM (VALUES (1), (2)) AS t(v)")
+				return err
+			})
+			its.NoErr(err)
+			its.Equal(methods, []Method{MethodTx, MethodGet, MethodExec}) // tx querier inherits the chain
+		})
+	})
+}
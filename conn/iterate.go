@@ -0,0 +1,49 @@
+package conn
+
+import (
+	"context"
+	"iter"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+// Queryer executes queries returning rows, Querier and cluster.Conn implement it.
+type Queryer interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	ScanAPI() *pgxscan.API
+}
+
+// Iterate executes a query and scans rows into a T one by one as the sequence is ranged over,
+// so the whole result is never held in memory.
+// Rows are closed when the loop ends, including early break.
+// An error stops the sequence: it is yielded with the zero T as the last pair.
+func Iterate[T any](ctx context.Context, q Queryer, sql string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		rows, err := q.Query(ctx, sql, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		scanner := q.ScanAPI().NewRowScanner(rows)
+		for rows.Next() {
+			var dst T
+			if err := scanner.Scan(&dst); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			if !yield(dst, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package conn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/matryer/is"
+)
+
+func TestIterate(t *testing.T) {
+	t.Run("all rows", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			type row struct {
+				ID    int
+				Value string
+			}
+
+			var rows []row
+			for r, err := range Iterate[row](ctx, querier, `SELECT * FROM (VALUES (1, 'one'), (2, 'two')) AS t(id, value)`) {
+				its.NoErr(err)
+				rows = append(rows, r)
+			}
+			its.Equal(rows, []row{{ID: 1, Value: "one"}, {ID: 2, Value: "two"}})
+		})
+	})
+
+	t.Run("early break closes rows", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			for n, err := range Iterate[int](ctx, querier, `SELECT generate_series(1, 1000)`) {
+				its.NoErr(err)
+				its.Equal(n, 1)
+				break
+			}
+
+			// connection is usable again only if rows were closed
+			var value int
+			its.NoErr(querier.Get(ctx, &value, `SELECT 2`))
+			its.Equal(value, 2)
+		})
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			var errs int
+			for _, err := range Iterate[int](ctx, querier, `SELECT 1/0`) {
+				its.True(err != nil)
+				errs++
+			}
+			its.Equal(errs, 1)
+		})
+	})
+}
@@ -11,21 +11,35 @@ type Querier interface {
 	Select(ctx context.Context, dst any, sql string, args ...any) error
 	Get(ctx context.Context, dst any, sql string, args ...any) error
 	Exec(ctx context.Context, sql string, args ...any) (int64, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	SendBatch(ctx context.Context, b *Batch) error
+	CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error)
 	Tx(ctx context.Context, f func(q Querier) error, opts ...TxOption) error
 	Conn(ctx context.Context) PgxConn
+	ScanAPI() *pgxscan.API
 }
 
 var _ Querier = &wrappedConn{}
 
 type wrappedConn struct {
-	conn    PgxConn
-	scanAPI *pgxscan.API
+	conn         PgxConn
+	scanAPI      *pgxscan.API
+	interceptors []Interceptor
+	// root is the connection transactions of conn were started on, it begins PropagationRequiresNew transactions.
+	root PgxConn
 }
 
-func WrapConn(conn PgxConn, scanAPI *pgxscan.API) *wrappedConn {
+func WrapConn(conn PgxConn, scanAPI *pgxscan.API, opts ...Option) *wrappedConn {
+	options := &Options{}
+	for _, o := range opts {
+		o(options)
+	}
+
 	return &wrappedConn{
-		conn:    conn,
-		scanAPI: scanAPI,
+		conn:         conn,
+		scanAPI:      scanAPI,
+		interceptors: options.Interceptors,
+		root:         conn,
 	}
 }
 
@@ -35,55 +49,189 @@ func WrapConn(conn PgxConn, scanAPI *pgxscan.API) *wrappedConn {
 // Before starting, Select resets the destination slice,
 // so if it's not empty it will overwrite all existing elements.
 func (n *wrappedConn) Select(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
-	rows, err := n.Conn(ctx).Query(ctx, sql, args...)
-	if err != nil {
-		return err
-	}
+	return n.intercept(ctx, &Call{Method: MethodSelect, SQL: sql, Args: args, Dst: dst}, func(ctx context.Context, c *Call) error {
+		rows, err := n.Conn(ctx).Query(ctx, c.SQL, c.Args...)
+		if err != nil {
+			return err
+		}
 
-	return n.scanAPI.ScanAll(dst, rows)
+		return n.scanAPI.ScanAll(c.Dst, rows)
+	})
 }
 
 // Get iterates all rows to the end and makes sure that there was exactly one row
 // otherwise it returns an error.
 // It scans data from single row into the destination.
 func (n *wrappedConn) Get(ctx context.Context, dst interface{}, sql string, args ...interface{}) error {
-	rows, err := n.Conn(ctx).Query(ctx, sql, args...)
-	if err != nil {
-		return err
-	}
+	return n.intercept(ctx, &Call{Method: MethodGet, SQL: sql, Args: args, Dst: dst}, func(ctx context.Context, c *Call) error {
+		rows, err := n.Conn(ctx).Query(ctx, c.SQL, c.Args...)
+		if err != nil {
+			return err
+		}
 
-	return n.scanAPI.ScanOne(dst, rows)
+		return n.scanAPI.ScanOne(c.Dst, rows)
+	})
 }
 
 // Exec executes a query without returning any rows and return affected rows.
 func (n *wrappedConn) Exec(ctx context.Context, sql string, args ...interface{}) (int64, error) {
-	res, err := n.Conn(ctx).Exec(ctx, sql, args...)
+	call := &Call{Method: MethodExec, SQL: sql, Args: args}
+	err := n.intercept(ctx, call, func(ctx context.Context, c *Call) error {
+		res, err := n.Conn(ctx).Exec(ctx, c.SQL, c.Args...)
+		if err != nil {
+			return err
+		}
+
+		c.RowsAffected = res.RowsAffected()
+		return nil
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return res.RowsAffected(), nil
+	return call.RowsAffected, nil
+}
+
+// Query executes a query and returns its rows, the caller must close them.
+// Interceptors see the call as finished once the query is sent, not when rows are read.
+func (n *wrappedConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	call := &Call{Method: MethodQuery, SQL: sql, Args: args}
+	err := n.intercept(ctx, call, func(ctx context.Context, c *Call) error {
+		rows, err := n.Conn(ctx).Query(ctx, c.SQL, c.Args...)
+		if err != nil {
+			return err
+		}
+
+		c.Rows = rows
+		return nil
+	})
+	if err != nil {
+		if call.Rows != nil {
+			call.Rows.Close()
+		}
+		return nil, err
+	}
+
+	return call.Rows, nil
+}
+
+// SendBatch sends statements of b in one round trip and reads their results into declared destinations.
+// An empty batch is not sent.
+func (n *wrappedConn) SendBatch(ctx context.Context, b *Batch) error {
+	if b.Len() == 0 {
+		return nil
+	}
+
+	return n.intercept(ctx, &Call{Method: MethodBatch, Batch: b}, func(ctx context.Context, c *Call) error {
+		return n.sendBatch(ctx, c.Batch)
+	})
+}
+
+// CopyFrom inserts rows, a slice of structs or pointers to structs, into table with the COPY protocol
+// and returns the number of copied rows.
+// Columns are named by db tags or snake cased field names like in Select and Get, fields tagged with "-" are skipped.
+// Names follow pgxscan.DefaultAPI whatever scan API the connection uses, nested structs fail with ErrNestedStruct.
+// The copy runs in the transaction of ctx, if any.
+func (n *wrappedConn) CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error) {
+	call := &Call{Method: MethodCopy, Table: table, Source: rows}
+	err := n.intercept(ctx, call, func(ctx context.Context, c *Call) error {
+		copied, err := n.copyFrom(ctx, c.Table, c.Source)
+		if err != nil {
+			return err
+		}
+
+		c.RowsAffected = copied
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return call.RowsAffected, nil
 }
 
 // Tx starts a transaction and calls f. If f does not return an error the transaction is committed.
 // If f returns an error the transaction is rolled back.
+// If a transaction is in progress, in ctx or in the querier itself, f runs in a savepoint of it
+// unless WithPropagation says otherwise. Isolation level, access and deferrable modes of opts
+// are ignored for savepoints and savepoints are not retried, see WithRetry.
 func (n *wrappedConn) Tx(ctx context.Context, f func(q Querier) error, opts ...TxOption) error {
 	txOpts := &TxOptions{}
 	for _, o := range opts {
 		o(txOpts)
 	}
-	err := pgx.BeginFunc(ctx, n.Conn(ctx), func(txx pgx.Tx) error {
-		if err := txOpts.Apply(ctx, txx); err != nil {
+
+	return n.intercept(ctx, &Call{Method: MethodTx}, func(ctx context.Context, call *Call) error {
+		c, join, err := txOpts.propagate(n.Conn(ctx), n.root)
+		if err != nil {
 			return err
 		}
+		if join {
+			tx := c.(pgx.Tx) //nolint: forcetypeassert // Only a transaction is joined.
+			if err := f(n.bind(tx)); err != nil {
+				_ = setRollbackOnly(tx) // a transaction begun elsewhere gets the error only
+				return err
+			}
+			return nil
+		}
+
+		retry := txOpts.Retry
+		if nestedTx(c) {
+			retry = nil
+		}
 
-		return f(WrapConn(txx, n.scanAPI))
+		return retry.run(ctx, func() error {
+			var fErr error
+			err := pgx.BeginFunc(ctx, beginner{conn: c, opts: txOpts.BeginOptions()}, func(txx pgx.Tx) error {
+				if err := txOpts.Apply(ctx, txx); err != nil {
+					return err
+				}
+
+				fErr = f(n.bind(txx))
+				return fErr
+			})
+
+			call.TxErr = nil
+			if fErr == nil {
+				call.TxErr = err
+			}
+
+			return err
+		})
 	})
+}
 
-	return err
+// bind returns querier of tx with the same scan API, interceptors and root connection.
+func (n *wrappedConn) bind(tx pgx.Tx) *wrappedConn {
+	return &wrappedConn{
+		conn:         tx,
+		scanAPI:      n.scanAPI,
+		interceptors: n.interceptors,
+		root:         n.root,
+	}
 }
 
+// intercept runs h for call through the interceptor chain.
+func (n *wrappedConn) intercept(ctx context.Context, call *Call, h Handler) error {
+	if len(n.interceptors) == 0 {
+		return h(ctx, call)
+	}
+
+	return chain(n.interceptors, h)(ctx, call)
+}
+
+// ScanAPI returns the scan API used to scan rows.
+func (n *wrappedConn) ScanAPI() *pgxscan.API {
+	return n.scanAPI
+}
+
+// Conn returns connection for queries: the transaction of the querier, the transaction in ctx
+// or the wrapped connection, in that order.
 func (n *wrappedConn) Conn(ctx context.Context) PgxConn {
+	if nestedTx(n.conn) {
+		return n.conn
+	}
+
 	if conn, ok := TxFromContext(ctx); ok {
 		return conn
 	}
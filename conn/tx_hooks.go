@@ -0,0 +1,162 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// txState is a transaction started by Tx or TxManager, it runs hooks once the outermost transaction ends.
+// A savepoint passes its hooks to the parent when released and keeps only rollback hooks when rolled back.
+// A savepoint of a transaction begun elsewhere, e.g. passed to NewTxContext or used by txdb, takes no hooks:
+// its release is not a commit and the transaction can still be rolled back by its owner.
+type txState struct {
+	pgx.Tx
+	parent *txState
+	// foreign is set for savepoints of a transaction begun elsewhere and their savepoints.
+	foreign bool
+	// rollbackOnly is set when a transaction joined to this one fails, see setRollbackOnly.
+	rollbackOnly atomic.Bool
+
+	mu         sync.Mutex
+	done       bool
+	onCommit   []func(context.Context)
+	onRollback []func(context.Context)
+	// always run after the outermost transaction ends, they are rollback hooks of rolled back savepoints.
+	always []func(context.Context)
+}
+
+// Begin starts a savepoint of the transaction.
+func (s *txState) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := s.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txState{Tx: tx, parent: s, foreign: s.foreign}, nil
+}
+
+// Commit commits the transaction or releases the savepoint.
+// A failed commit is handled as rollback, a rollback-only transaction is rolled back and ErrRollbackOnly returned.
+func (s *txState) Commit(ctx context.Context) error {
+	if s.rollbackOnly.Load() {
+		if err := s.Rollback(ctx); err != nil {
+			return err
+		}
+		return ErrRollbackOnly
+	}
+
+	err := s.Tx.Commit(ctx)
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return err
+	}
+
+	s.end(ctx, err == nil)
+	return err
+}
+
+// Rollback rolls back the transaction or the savepoint.
+func (s *txState) Rollback(ctx context.Context) error {
+	err := s.Tx.Rollback(ctx)
+	if errors.Is(err, pgx.ErrTxClosed) {
+		return err
+	}
+
+	s.end(ctx, false)
+	return err
+}
+
+// end runs the hooks of the outermost transaction or passes them to the parent of a savepoint.
+func (s *txState) end(ctx context.Context, committed bool) {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return
+	}
+	s.done = true
+	run := slices.Concat(s.onRollback, s.always)
+	if committed {
+		run = slices.Concat(s.onCommit, s.always)
+	}
+	onCommit, onRollback := s.onCommit, s.onRollback
+	s.mu.Unlock()
+
+	if s.parent == nil {
+		for _, fn := range run {
+			fn(ctx)
+		}
+		return
+	}
+
+	s.parent.mu.Lock()
+	defer s.parent.mu.Unlock()
+	if committed {
+		s.parent.onCommit = append(s.parent.onCommit, onCommit...)
+		s.parent.onRollback = append(s.parent.onRollback, onRollback...)
+		s.parent.always = append(s.parent.always, s.always...)
+		return
+	}
+	s.parent.always = append(s.parent.always, run...)
+}
+
+// register adds fn to the commit or rollback hooks.
+func (s *txState) register(commit bool, fn func(context.Context)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		return errors.New("transaction already ended")
+	}
+	if commit {
+		s.onCommit = append(s.onCommit, fn)
+	} else {
+		s.onRollback = append(s.onRollback, fn)
+	}
+
+	return nil
+}
+
+// OnCommit registers fn to run after the transaction in ctx commits, e.g. to publish events or invalidate caches.
+// Hooks registered in a savepoint run only if the savepoint is released and the outermost transaction commits.
+// It returns ErrNoTx if ctx carries no transaction started by Tx or TxManager, see QuerierTxContext,
+// or only a savepoint of a transaction begun elsewhere, e.g. passed to NewTxContext or used by txdb.
+func OnCommit(ctx context.Context, fn func(ctx context.Context)) error {
+	return registerHook(ctx, true, fn)
+}
+
+// OnRollback registers fn to run after the transaction in ctx is rolled back or fails to commit.
+// Hooks registered in a savepoint that is rolled back run after the outermost transaction ends,
+// whether it commits or not.
+// It returns ErrNoTx like OnCommit.
+func OnRollback(ctx context.Context, fn func(ctx context.Context)) error {
+	return registerHook(ctx, false, fn)
+}
+
+func registerHook(ctx context.Context, commit bool, fn func(context.Context)) error {
+	tx, _ := TxFromContext(ctx)
+	state, ok := tx.(*txState)
+	if !ok {
+		return fmt.Errorf("register transaction hook: %w", ErrNoTx)
+	}
+	if state.foreign {
+		return fmt.Errorf("register transaction hook: outermost transaction begun elsewhere: %w", ErrNoTx)
+	}
+
+	return state.register(commit, fn)
+}
+
+// QuerierTxContext returns a new context carrying the transaction of q, e.g. the Querier passed to a Tx callback.
+// ctx is returned unchanged if q is not in a transaction.
+func QuerierTxContext(ctx context.Context, q Querier) context.Context {
+	tx, ok := q.Conn(ctx).(pgx.Tx)
+	if !ok {
+		return ctx
+	}
+
+	return NewTxContext(ctx, tx)
+}
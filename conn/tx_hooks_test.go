@@ -0,0 +1,160 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/matryer/is"
+)
+
+// stubTx is pgx.Tx that only begins savepoints and ends.
+type stubTx struct {
+	pgx.Tx
+	closed    bool
+	commitErr error
+}
+
+func (tx *stubTx) Begin(context.Context) (pgx.Tx, error) {
+	return &stubTx{commitErr: tx.commitErr}, nil
+}
+
+func (tx *stubTx) Commit(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return tx.commitErr
+}
+
+func (tx *stubTx) Rollback(context.Context) error {
+	if tx.closed {
+		return pgx.ErrTxClosed
+	}
+	tx.closed = true
+	return nil
+}
+
+func TestTxHooks(t *testing.T) {
+	// record returns hook appending name to calls.
+	record := func(calls *[]string, name string) func(context.Context) {
+		return func(context.Context) { *calls = append(*calls, name) }
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		is := is.New(t)
+
+		var calls []string
+		tx := &txState{Tx: &stubTx{}}
+		ctx := NewTxContext(t.Context(), tx)
+		is.NoErr(OnCommit(ctx, record(&calls, "commit 1")))
+		is.NoErr(OnCommit(ctx, record(&calls, "commit 2")))
+		is.NoErr(OnRollback(ctx, record(&calls, "rollback")))
+
+		is.NoErr(tx.Commit(ctx))
+		is.True(errors.Is(tx.Rollback(ctx), pgx.ErrTxClosed))
+		is.Equal(calls, []string{"commit 1", "commit 2"})
+		is.True(OnCommit(ctx, record(&calls, "late")) != nil)
+	})
+
+	t.Run("failed commit", func(t *testing.T) {
+		is := is.New(t)
+
+		var calls []string
+		tx := &txState{Tx: &stubTx{commitErr: pgx.ErrTxCommitRollback}}
+		ctx := NewTxContext(t.Context(), tx)
+		is.NoErr(OnCommit(ctx, record(&calls, "commit")))
+		is.NoErr(OnRollback(ctx, record(&calls, "rollback")))
+
+		is.True(errors.Is(tx.Commit(ctx), pgx.ErrTxCommitRollback))
+		is.Equal(calls, []string{"rollback"})
+	})
+
+	t.Run("savepoints", func(t *testing.T) {
+		is := is.New(t)
+
+		var calls []string
+		tx := &txState{Tx: &stubTx{}}
+		ctx := NewTxContext(t.Context(), tx)
+		is.NoErr(OnCommit(ctx, record(&calls, "outer commit")))
+
+		released, err := tx.Begin(ctx)
+		is.NoErr(err)
+		releasedCtx := NewTxContext(ctx, released)
+		is.NoErr(OnCommit(releasedCtx, record(&calls, "released commit")))
+		is.NoErr(released.Commit(ctx))
+
+		rolledBack, err := tx.Begin(ctx)
+		is.NoErr(err)
+		rolledBackCtx := NewTxContext(ctx, rolledBack)
+		is.NoErr(OnCommit(rolledBackCtx, record(&calls, "discarded commit")))
+		is.NoErr(OnRollback(rolledBackCtx, record(&calls, "savepoint rollback")))
+		is.NoErr(rolledBack.Rollback(ctx))
+
+		is.Equal(len(calls), 0) // hooks wait for the outermost transaction
+		is.NoErr(tx.Commit(ctx))
+		is.Equal(calls, []string{"outer commit", "released commit", "savepoint rollback"})
+	})
+
+	t.Run("no transaction", func(t *testing.T) {
+		is := is.New(t)
+
+		is.True(errors.Is(OnCommit(t.Context(), func(context.Context) {}), ErrNoTx))
+		is.True(errors.Is(OnRollback(NewTxContext(t.Context(), &stubTx{}), func(context.Context) {}), ErrNoTx))
+	})
+
+	t.Run("savepoint of transaction begun elsewhere", func(t *testing.T) {
+		is := is.New(t)
+
+		ctx := NewTxContext(t.Context(), &stubTx{})
+		savepoint, err := beginner{conn: &stubTx{}}.Begin(ctx)
+		is.NoErr(err)
+		is.True(errors.Is(OnCommit(NewTxContext(ctx, savepoint), func(context.Context) {}), ErrNoTx)) // release is not a commit
+
+		nested, err := savepoint.Begin(ctx)
+		is.NoErr(err)
+		is.True(errors.Is(OnRollback(NewTxContext(ctx, nested), func(context.Context) {}), ErrNoTx))
+	})
+
+	t.Run("Tx", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			var calls []string
+			err := querier.Tx(ctx, func(q Querier) error {
+				txCtx := QuerierTxContext(ctx, q)
+				its.NoErr(OnCommit(txCtx, record(&calls, "commit")))
+
+				err := q.Tx(ctx, func(inner Querier) error {
+					its.NoErr(OnCommit(QuerierTxContext(ctx, inner), record(&calls, "discarded commit")))
+					return errors.New("rollback savepoint")
+				})
+				its.True(err != nil)
+
+				return nil
+			})
+			its.NoErr(err)
+			its.Equal(calls, []string{"commit"})
+
+			txCtx, _, rollback, err := NewTxManager(querier).NewTx(ctx)
+			its.NoErr(err)
+			its.NoErr(OnRollback(txCtx, record(&calls, "rollback")))
+			its.NoErr(rollback())
+			its.Equal(calls, []string{"commit", "rollback"})
+
+			raw, err := conn.Begin(ctx)
+			its.NoErr(err)
+			defer raw.Rollback(ctx) //nolint: errcheck // The owner rolls back after the savepoint is released.
+
+			err = querier.Tx(NewTxContext(ctx, raw), func(q Querier) error {
+				its.True(errors.Is(OnCommit(QuerierTxContext(ctx, q), record(&calls, "savepoint")), ErrNoTx))
+				return nil
+			})
+			its.NoErr(err)
+			its.Equal(calls, []string{"commit", "rollback"})
+		})
+	})
+}
@@ -2,6 +2,8 @@ package conn
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // TxManager provides transaction management abstraction for service layer.
@@ -22,14 +24,26 @@ func NewTxManager(q Querier) TxManager {
 
 // NewTx starts a new transaction and returns a context with the transaction embedded,
 // along with commit and rollback functions.
+// A transaction in ctx is handled according to WithPropagation, commit of a joined transaction does nothing
+// and rollback marks it rollback-only, so the commit of its owner fails with ErrRollbackOnly.
 func (tm *txManager) NewTx(ctx context.Context, opts ...TxOption) (context.Context, func() error, func() error, error) {
 	txOpts := &TxOptions{}
 	for _, o := range opts {
 		o(txOpts)
 	}
 
-	conn := tm.querier.Conn(ctx)
-	tx, err := conn.Begin(ctx)
+	c, join, err := txOpts.propagate(tm.querier.Conn(ctx), tm.querier.Conn(withoutTx(ctx)))
+	if err != nil {
+		return ctx, nil, nil, err
+	}
+	if join {
+		tx := c.(pgx.Tx) //nolint: forcetypeassert // Only a transaction is joined.
+		commit := func() error { return nil }
+		rollback := func() error { return setRollbackOnly(tx) }
+		return NewTxContext(ctx, tx), commit, rollback, nil
+	}
+
+	tx, err := beginner{conn: c, opts: txOpts.BeginOptions()}.Begin(ctx)
 	if err != nil {
 		return ctx, nil, nil, err
 	}
@@ -17,6 +17,15 @@ const (
 type TxOptions struct {
 	TransactionTimeout int64
 	StatementTimeout   int64
+
+	IsoLevel       pgx.TxIsoLevel
+	AccessMode     pgx.TxAccessMode
+	DeferrableMode pgx.TxDeferrableMode
+
+	// Retry is set by WithRetry.
+	Retry *TxRetryPolicy
+	// Propagation is set by WithPropagation.
+	Propagation Propagation
 }
 
 // TxOption is a function that configures TxOptions.
@@ -36,6 +45,71 @@ func StatementTimeout(d time.Duration) TxOption {
 	}
 }
 
+// IsolationLevel sets transaction isolation level, e.g. pgx.Serializable or pgx.RepeatableRead.
+func IsolationLevel(level pgx.TxIsoLevel) TxOption {
+	return func(o *TxOptions) {
+		o.IsoLevel = level
+	}
+}
+
+// ReadOnly makes the transaction read only.
+func ReadOnly() TxOption {
+	return func(o *TxOptions) {
+		o.AccessMode = pgx.ReadOnly
+	}
+}
+
+// Deferrable makes the transaction deferrable, it has effect only for serializable read only transactions.
+func Deferrable() TxOption {
+	return func(o *TxOptions) {
+		o.DeferrableMode = pgx.Deferrable
+	}
+}
+
+// BeginOptions returns options passed to BeginTx.
+func (opts *TxOptions) BeginOptions() pgx.TxOptions {
+	return pgx.TxOptions{
+		IsoLevel:       opts.IsoLevel,
+		AccessMode:     opts.AccessMode,
+		DeferrableMode: opts.DeferrableMode,
+	}
+}
+
+// beginner begins transactions of conn with options, the transactions run commit and rollback hooks
+// unless they are savepoints of a transaction begun elsewhere.
+// pgx.Tx has no BeginTx, a transaction in progress begins a savepoint and options are ignored.
+type beginner struct {
+	conn PgxConn
+	opts pgx.TxOptions
+}
+
+func (b beginner) Begin(ctx context.Context) (pgx.Tx, error) {
+	var (
+		tx  pgx.Tx
+		err error
+	)
+	switch conn := b.conn.(type) {
+	case *txState:
+		return conn.Begin(ctx)
+	case pgx.Tx:
+		if tx, err = conn.Begin(ctx); err != nil {
+			return nil, err
+		}
+		return &txState{Tx: tx, foreign: true}, nil
+	case interface {
+		BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
+	}:
+		tx, err = conn.BeginTx(ctx, b.opts)
+	default:
+		tx, err = conn.Begin(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &txState{Tx: tx}, nil
+}
+
 // Apply applies the timeout configuration to the given transaction.
 func (opts *TxOptions) Apply(ctx context.Context, tx pgx.Tx) error {
 	if opts.TransactionTimeout > 0 {
@@ -96,4 +96,44 @@ SELECT
 			})
 		})
 	})
+
+	t.Run("begin options", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			type modeRow struct {
+				Isolation  string `db:"transaction_isolation"`
+				ReadOnly   string `db:"transaction_read_only"`
+				Deferrable string `db:"transaction_deferrable"`
+			}
+			const modeQuery = `
+SELECT
+  CURRENT_SETTING('transaction_isolation')  AS transaction_isolation,
+  CURRENT_SETTING('transaction_read_only')  AS transaction_read_only,
+  CURRENT_SETTING('transaction_deferrable') AS transaction_deferrable;
+`
+			err := querier.Tx(ctx, func(q Querier) error {
+				var mode modeRow
+				its.NoErr(q.Get(ctx, &mode, modeQuery))
+				its.Equal(mode, modeRow{Isolation: "serializable", ReadOnly: "on", Deferrable: "on"})
+
+				_, err := q.Exec(ctx, `CREATE TEMP TABLE read_only_test (id int)`)
+				var pgErr *pgconn.PgError
+				its.True(errors.As(err, &pgErr))
+				its.Equal(pgErr.Code, "25006") // read_only_sql_transaction
+
+				return nil
+			}, IsolationLevel(pgx.Serializable), ReadOnly(), Deferrable())
+			its.True(err != nil) // commit of the aborted transaction fails
+
+			txCtx, commit, _, err := NewTxManager(querier).NewTx(ctx, IsolationLevel(pgx.RepeatableRead))
+			its.NoErr(err)
+
+			var isolation string
+			its.NoErr(querier.Get(txCtx, &isolation, `SELECT CURRENT_SETTING('transaction_isolation')`))
+			its.Equal(isolation, "repeatable read")
+			its.NoErr(commit())
+		})
+	})
 }
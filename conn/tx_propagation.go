@@ -0,0 +1,113 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrNoTx is returned by Tx and TxManager.NewTx with PropagationMandatory when there is no transaction in progress.
+	ErrNoTx = errors.New("no transaction in progress")
+	// ErrTxInProgress is returned by Tx and TxManager.NewTx with PropagationNever when a transaction is in progress.
+	ErrTxInProgress = errors.New("transaction already in progress")
+	// ErrNewTxUnsupported is returned for PropagationRequiresNew when the connection can't run
+	// another transaction alongside the one in progress, e.g. it is a single pgx.Conn or a pgx.Tx.
+	ErrNewTxUnsupported = errors.New("connection can't start an independent transaction")
+	// ErrRollbackOnly is returned by commit of a transaction rolled back because a transaction joined to it failed,
+	// see PropagationRequired and PropagationMandatory.
+	ErrRollbackOnly = errors.New("transaction is rollback-only")
+)
+
+// Propagation defines how Tx and TxManager.NewTx behave when a transaction is already in progress,
+// either in ctx or in the Querier passed to a Tx callback.
+type Propagation uint8
+
+const (
+	// PropagationNested runs in a savepoint of the transaction in progress or begins a new transaction. It is the default.
+	PropagationNested Propagation = iota
+	// PropagationRequired joins the transaction in progress or begins a new transaction.
+	PropagationRequired
+	// PropagationRequiresNew always begins an independent transaction on a separate pooled connection.
+	PropagationRequiresNew
+	// PropagationMandatory joins the transaction in progress and fails with ErrNoTx if there is none.
+	PropagationMandatory
+	// PropagationNever begins a new transaction and fails with ErrTxInProgress if one is in progress.
+	PropagationNever
+)
+
+// WithPropagation sets how the transaction relates to a transaction in progress, PropagationNested by default.
+// A joined transaction is committed or rolled back by its owner, options other than propagation are ignored for it.
+// If the joined part fails the transaction is marked rollback-only, its commit rolls it back and returns ErrRollbackOnly.
+func WithPropagation(p Propagation) TxOption {
+	return func(o *TxOptions) {
+		o.Propagation = p
+	}
+}
+
+// propagate returns the connection to begin the transaction on, or the transaction to join if join is true.
+// current is the connection queries would use, root is the connection of the querier outside any transaction.
+func (opts *TxOptions) propagate(current, root PgxConn) (c PgxConn, join bool, err error) {
+	inTx := nestedTx(current)
+	switch opts.Propagation {
+	case PropagationRequired:
+		return current, inTx, nil
+	case PropagationMandatory:
+		if !inTx {
+			return nil, false, ErrNoTx
+		}
+		return current, true, nil
+	case PropagationNever:
+		if inTx {
+			return nil, false, ErrTxInProgress
+		}
+		return current, false, nil
+	case PropagationRequiresNew:
+		if !inTx {
+			return current, false, nil
+		}
+		if !independent(root) {
+			return nil, false, ErrNewTxUnsupported
+		}
+		return root, false, nil
+	default:
+		return current, false, nil
+	}
+}
+
+// independent reports whether c can begin a transaction while another one is in progress.
+// Only pools hand out separate connections, a single connection is independent only while it is idle.
+func independent(c PgxConn) bool {
+	switch c := c.(type) {
+	case pgx.Tx:
+		return false
+	case interface{ PgConn() *pgconn.PgConn }:
+		return c.PgConn().TxStatus() == 'I'
+	default:
+		return true
+	}
+}
+
+// withoutTx returns a new context that carries no transaction.
+func withoutTx(ctx context.Context) context.Context {
+	if _, ok := TxFromContext(ctx); !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, txKey, nil)
+}
+
+// setRollbackOnly makes the commit of tx roll it back and fail with ErrRollbackOnly.
+// Only transactions started by Tx or TxManager can be marked.
+func setRollbackOnly(tx pgx.Tx) error {
+	state, ok := tx.(*txState)
+	if !ok {
+		return fmt.Errorf("%w: can't mark transaction begun elsewhere", ErrRollbackOnly)
+	}
+
+	state.rollbackOnly.Store(true)
+	return nil
+}
@@ -0,0 +1,156 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/matryer/is"
+)
+
+func TestPropagate(t *testing.T) {
+	var (
+		pool = &pgxpool.Pool{}
+		tx   = &pgxpool.Tx{}
+	)
+
+	tests := []struct {
+		name        string
+		propagation Propagation
+		current     PgxConn
+		want        PgxConn
+		join        bool
+		err         error
+	}{
+		{name: "nested without tx", propagation: PropagationNested, current: pool, want: pool},
+		{name: "nested in tx", propagation: PropagationNested, current: tx, want: tx},
+		{name: "required without tx", propagation: PropagationRequired, current: pool, want: pool},
+		{name: "required in tx", propagation: PropagationRequired, current: tx, want: tx, join: true},
+		{name: "requires new without tx", propagation: PropagationRequiresNew, current: pool, want: pool},
+		{name: "requires new in tx", propagation: PropagationRequiresNew, current: tx, want: pool},
+		{name: "mandatory without tx", propagation: PropagationMandatory, current: pool, err: ErrNoTx},
+		{name: "mandatory in tx", propagation: PropagationMandatory, current: tx, want: tx, join: true},
+		{name: "never without tx", propagation: PropagationNever, current: pool, want: pool},
+		{name: "never in tx", propagation: PropagationNever, current: tx, err: ErrTxInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			opts := &TxOptions{}
+			WithPropagation(tt.propagation)(opts)
+
+			c, join, err := opts.propagate(tt.current, pool)
+			is.True(errors.Is(err, tt.err))
+			is.True(c == tt.want)
+			is.Equal(join, tt.join)
+		})
+	}
+
+	t.Run("requires new on a transaction", func(t *testing.T) {
+		is := is.New(t)
+
+		opts := &TxOptions{Propagation: PropagationRequiresNew}
+		_, _, err := opts.propagate(tx, tx)
+		is.True(errors.Is(err, ErrNewTxUnsupported))
+	})
+}
+
+func TestTxPropagation(t *testing.T) {
+	t.Run("Querier", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			err := querier.Tx(ctx, func(Querier) error { return nil }, WithPropagation(PropagationMandatory))
+			its.True(errors.Is(err, ErrNoTx))
+
+			err = querier.Tx(ctx, func(q Querier) error {
+				var joined bool
+				err := q.Tx(ctx, func(inner Querier) error {
+					joined = inner.Conn(ctx) == q.Conn(ctx)
+					return nil
+				}, WithPropagation(PropagationRequired))
+				its.NoErr(err)
+				its.True(joined)
+
+				err = q.Tx(ctx, func(Querier) error { return nil }, WithPropagation(PropagationNever))
+				its.True(errors.Is(err, ErrTxInProgress))
+
+				// a single connection can't run a second transaction
+				err = q.Tx(ctx, func(Querier) error { return nil }, WithPropagation(PropagationRequiresNew))
+				its.True(errors.Is(err, ErrNewTxUnsupported))
+
+				return nil
+			})
+			its.NoErr(err)
+		})
+	})
+
+	t.Run("TxManager", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			txMgr := NewTxManager(WrapConn(conn, pgxscan.DefaultAPI))
+
+			_, _, _, err := txMgr.NewTx(ctx, WithPropagation(PropagationMandatory))
+			its.True(errors.Is(err, ErrNoTx))
+
+			txCtx, commit, _, err := txMgr.NewTx(ctx)
+			its.NoErr(err)
+
+			joinedCtx, joinedCommit, _, err := txMgr.NewTx(txCtx, WithPropagation(PropagationRequired))
+			its.NoErr(err)
+			joined, _ := TxFromContext(joinedCtx)
+			outer, _ := TxFromContext(txCtx)
+			its.True(joined == outer)
+			its.NoErr(joinedCommit()) // the owner decides
+
+			_, _, _, err = txMgr.NewTx(txCtx, WithPropagation(PropagationNever))
+			its.True(errors.Is(err, ErrTxInProgress))
+
+			its.NoErr(commit())
+		})
+	})
+}
+
+func TestJoinedRollback(t *testing.T) {
+	t.Run("TxManager", func(t *testing.T) {
+		is := is.New(t)
+
+		outer := &txState{Tx: &stubTx{}}
+		txMgr := NewTxManager(WrapConn(nil, pgxscan.DefaultAPI))
+		ctx := NewTxContext(t.Context(), outer)
+
+		_, commit, rollback, err := txMgr.NewTx(ctx, WithPropagation(PropagationRequired))
+		is.NoErr(err)
+		is.NoErr(rollback())
+		is.NoErr(commit()) // the owner ends the transaction
+
+		is.True(errors.Is(outer.Commit(ctx), ErrRollbackOnly))
+		is.True(errors.Is(outer.Rollback(ctx), pgx.ErrTxClosed)) // rolled back by the commit
+	})
+
+	t.Run("Querier", func(t *testing.T) {
+		is := is.New(t)
+
+		outer := &txState{Tx: &stubTx{}}
+		ctx := NewTxContext(t.Context(), outer)
+		failure := errors.New("failure")
+
+		err := WrapConn(nil, pgxscan.DefaultAPI).Tx(ctx, func(Querier) error { return failure }, WithPropagation(PropagationMandatory))
+		is.Equal(err, failure)
+		is.True(errors.Is(outer.Commit(ctx), ErrRollbackOnly))
+	})
+
+	t.Run("transaction begun elsewhere", func(t *testing.T) {
+		is := is.New(t)
+
+		txMgr := NewTxManager(WrapConn(nil, pgxscan.DefaultAPI))
+		_, _, rollback, err := txMgr.NewTx(NewTxContext(t.Context(), &stubTx{}), WithPropagation(PropagationRequired))
+		is.NoErr(err)
+		is.True(errors.Is(rollback(), ErrRollbackOnly)) // the failure is not swallowed
+	})
+}
@@ -0,0 +1,104 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	defaultTxRetryAttempts = 3
+	defaultTxRetryBackoff  = 10 * time.Millisecond
+)
+
+// TxRetryPolicy configures retries of transactions failed because of a serialization failure or a deadlock.
+type TxRetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one, 3 if not positive.
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, 10ms if not positive. It doubles with each next attempt
+	// and is randomized between half and the full value, so conflicting transactions do not retry in lockstep.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// OnRetry is called with the error of the failed attempt before the next one, attempts are counted from 1.
+	OnRetry func(ctx context.Context, attempt int, err error)
+}
+
+// WithRetry makes Tx call f again in a new transaction while it fails with a serialization failure
+// or a deadlock, see IsRetryable. f must be safe to run more than once.
+// Transactions started in a transaction in progress are not retried, the outer transaction is aborted anyway.
+// TxManager ignores the option, the caller owns the transaction there.
+func WithRetry(policy TxRetryPolicy) TxOption {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultTxRetryAttempts
+	}
+	if policy.Backoff <= 0 {
+		policy.Backoff = defaultTxRetryBackoff
+	}
+
+	return func(o *TxOptions) {
+		o.Retry = &policy
+	}
+}
+
+// IsRetryable reports whether err is a serialization failure (40001) or a deadlock (40P01),
+// the transaction can succeed if it is run again.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+// run calls fn and calls it again according to the policy while it fails with a retryable error.
+// A nil policy calls fn once.
+func (p *TxRetryPolicy) run(ctx context.Context, fn func() error) error {
+	err := fn()
+	if p == nil {
+		return err
+	}
+
+	for attempt := 1; attempt < p.MaxAttempts && IsRetryable(err); attempt++ {
+		if p.OnRetry != nil {
+			p.OnRetry(ctx, attempt, err)
+		}
+
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		err = fn()
+	}
+
+	return err
+}
+
+// delay returns the randomized pause after failed attempt, attempts are counted from 1.
+func (p *TxRetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	half := d / 2
+	return half + rand.N(d-half+1) //nolint: gosec // Jitter does not need a secure random source.
+}
+
+// nestedTx reports whether a transaction started on c is a savepoint of a transaction in progress.
+func nestedTx(c PgxConn) bool {
+	_, ok := c.(pgx.Tx)
+	return ok
+}
@@ -0,0 +1,152 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/matryer/is"
+)
+
+func TestTxRetryPolicy(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+
+	t.Run("retries retryable errors", func(t *testing.T) {
+		is := is.New(t)
+
+		var retried []int
+		p := TxRetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			OnRetry: func(_ context.Context, attempt int, err error) {
+				is.True(IsRetryable(err))
+				retried = append(retried, attempt)
+			},
+		}
+
+		calls := 0
+		err := p.run(t.Context(), func() error {
+			calls++
+			if calls < 3 {
+				return serializationFailure
+			}
+			return nil
+		})
+		is.NoErr(err)
+		is.Equal(calls, 3)
+		is.Equal(retried, []int{1, 2})
+	})
+
+	t.Run("stops after max attempts", func(t *testing.T) {
+		is := is.New(t)
+
+		calls := 0
+		p := TxRetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+		err := p.run(t.Context(), func() error {
+			calls++
+			return &pgconn.PgError{Code: "40P01"}
+		})
+		is.True(IsRetryable(err))
+		is.Equal(calls, 2)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		is := is.New(t)
+
+		calls := 0
+		p := TxRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+		err := p.run(t.Context(), func() error {
+			calls++
+			return &pgconn.PgError{Code: "23505"}
+		})
+		is.True(err != nil)
+		is.Equal(calls, 1)
+
+		var nilPolicy *TxRetryPolicy
+		is.Equal(nilPolicy.run(t.Context(), func() error { return serializationFailure }), serializationFailure)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		is := is.New(t)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		calls := 0
+		p := TxRetryPolicy{MaxAttempts: 3, Backoff: time.Hour}
+		err := p.run(ctx, func() error {
+			calls++
+			cancel()
+			return serializationFailure
+		})
+		is.Equal(err, serializationFailure)
+		is.Equal(calls, 1)
+	})
+
+	t.Run("jittered backoff", func(t *testing.T) {
+		is := is.New(t)
+
+		p := TxRetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+		for range 100 {
+			d := p.delay(1)
+			is.True(d >= 5*time.Millisecond && d <= 10*time.Millisecond)
+
+			d = p.delay(5)
+			is.True(d >= 15*time.Millisecond && d <= 30*time.Millisecond)
+		}
+
+		p = TxRetryPolicy{Backoff: 50 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+		d := p.delay(1)
+		is.True(d >= 15*time.Millisecond && d <= 30*time.Millisecond) // first delay is capped too
+	})
+
+	t.Run("invalid policy gets defaults", func(t *testing.T) {
+		is := is.New(t)
+
+		opts := &TxOptions{}
+		WithRetry(TxRetryPolicy{MaxAttempts: -1, Backoff: -time.Millisecond})(opts)
+		is.Equal(opts.Retry.MaxAttempts, defaultTxRetryAttempts)
+		is.Equal(opts.Retry.Backoff, defaultTxRetryBackoff)
+		is.True(opts.Retry.delay(1) > 0) // negative backoff does not panic
+	})
+
+	t.Run("Tx", func(t *testing.T) {
+		TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, conn *pgx.Conn) {
+			its := is.New(t)
+			querier := WrapConn(conn, pgxscan.DefaultAPI)
+
+			attempts := 0
+			err := querier.Tx(ctx, func(q Querier) error {
+				attempts++
+				if attempts == 1 {
+					return serializationFailure
+				}
+
+				// a transaction in progress is not retried
+				nested := 0
+				err := q.Tx(ctx, func(Querier) error {
+					nested++
+					return serializationFailure
+				}, WithRetry(TxRetryPolicy{Backoff: time.Millisecond}))
+				its.Equal(err, serializationFailure)
+				its.Equal(nested, 1)
+
+				return nil
+			}, IsolationLevel(pgx.Serializable), WithRetry(TxRetryPolicy{Backoff: time.Millisecond}))
+			its.NoErr(err)
+			its.Equal(attempts, 2)
+		})
+	})
+}
+
+func TestWithRetryDefaults(t *testing.T) {
+	is := is.New(t)
+
+	opts := &TxOptions{}
+	WithRetry(TxRetryPolicy{})(opts)
+	is.Equal(opts.Retry.MaxAttempts, defaultTxRetryAttempts)
+	is.Equal(opts.Retry.Backoff, defaultTxRetryBackoff)
+	is.True(!IsRetryable(errors.New("40001")))
+}
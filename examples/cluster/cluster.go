@@ -46,6 +46,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Routing hints force the node for a call chain regardless of the ConnPicker.
+	err = db.Get(cluster.WithPrimary(ctx), &count, "SELECT COUNT(*) FROM table")
+	if err != nil {
+		logger.Error("failed to get from primary", "error", err)
+		os.Exit(1)
+	}
+
 	// Write queries are directed to the primary with Exec.
 	// Always use Exec for INSERTS, UPDATES
 	_, err = db.Exec(ctx, "UPDATE table SET something = 1")
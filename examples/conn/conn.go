@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/MrEhbr/pgxext/v2/querylog"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -29,7 +30,9 @@ func main() {
 		os.Exit(1)
 	}
 
-	wrapped := conn.WrapConn(db, pgxscan.DefaultAPI)
+	wrapped := conn.WrapConn(db, pgxscan.DefaultAPI, conn.WithInterceptors(
+		querylog.New(logger, querylog.WithSlowThreshold(200*time.Millisecond)),
+	))
 
 	var count int
 	err = wrapped.Get(ctx, &count, "SELECT COUNT(*) FROM table")
@@ -0,0 +1,315 @@
+// Package keyset implements keyset pagination on top of conn.Selector with tamper-evident cursor tokens.
+package keyset
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/jackc/pgx/v5"
+)
+
+const minSecretLength = 16
+
+var (
+	// ErrInvalidCursor is returned when a cursor token is malformed, tampered with or made for other keys.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrShortSecret is returned by New when the signing secret is shorter than 16 bytes.
+	ErrShortSecret = errors.New("secret must be at least 16 bytes")
+)
+
+// Key is a column the pages are ordered by.
+type Key struct {
+	// Column is the name of a result column of the base query.
+	Column string
+	// Desc orders the column in descending order.
+	Desc bool
+}
+
+// Asc returns a key ordered in ascending order.
+func Asc(column string) Key {
+	return Key{Column: column}
+}
+
+// Desc returns a key ordered in descending order.
+func Desc(column string) Key {
+	return Key{Column: column, Desc: true}
+}
+
+// Query describes a page request.
+type Query[T any] struct {
+	// SQL is the base query without ORDER BY and LIMIT, its result must include key columns.
+	SQL  string
+	Args []any
+	// Keys the pages are ordered by, together they must be unique and not null, e.g. created_at and id.
+	Keys []Key
+	// KeyOf returns values of Keys for a row.
+	KeyOf func(row T) []any
+	// Limit is the maximum number of rows in a page.
+	Limit int
+	// Cursor is the Next or Prev token of a previous page, empty for the first page.
+	Cursor string
+}
+
+// Page is a page of rows.
+type Page[T any] struct {
+	Items []T
+	// Next is the cursor of the following page, empty if there are no more rows.
+	Next string
+	// Prev is the cursor of the preceding page, empty on the first page.
+	Prev string
+}
+
+// Paginator signs and verifies cursor tokens.
+type Paginator struct {
+	secret []byte
+}
+
+// New returns a paginator signing cursor tokens with secret.
+func New(secret []byte) (*Paginator, error) {
+	if len(secret) < minSecretLength {
+		return nil, ErrShortSecret
+	}
+
+	return &Paginator{secret: slices.Clone(secret)}, nil
+}
+
+// token is the signed content of a cursor.
+type token struct {
+	// Backward is true for Prev cursors.
+	Backward bool `json:"b,omitempty"`
+	// Values of the keys in PostgreSQL text format.
+	Values []string `json:"v"`
+}
+
+// Fetch returns the page of q selected with s.
+// The base query is wrapped as a subquery filtered by the cursor position, ordered by keys and limited.
+func Fetch[T any](ctx context.Context, s conn.Selector, p *Paginator, q Query[T]) (Page[T], error) {
+	if len(q.Keys) == 0 || q.KeyOf == nil || q.Limit <= 0 {
+		return Page[T]{}, errors.New("keyset query needs keys, KeyOf and positive limit")
+	}
+
+	var cursor *token
+	if q.Cursor != "" {
+		t, err := p.decode(q.Cursor, q.Keys)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		cursor = &t
+	}
+
+	sql, args := buildQuery(q.SQL, q.Args, q.Keys, cursor, q.Limit+1)
+
+	var rows []T
+	if err := s.Select(ctx, &rows, sql, args...); err != nil {
+		return Page[T]{}, err
+	}
+
+	backward := cursor != nil && cursor.Backward
+	more := len(rows) > q.Limit
+	if more {
+		rows = rows[:q.Limit]
+	}
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	page := Page[T]{Items: rows}
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	// rows exist on the side the cursor came from.
+	hasNext, hasPrev := more, cursor != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+
+	var err error
+	if hasNext {
+		if page.Next, err = p.encode(false, q.KeyOf(rows[len(rows)-1]), q.Keys); err != nil {
+			return Page[T]{}, err
+		}
+	}
+	if hasPrev {
+		if page.Prev, err = p.encode(true, q.KeyOf(rows[0]), q.Keys); err != nil {
+			return Page[T]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// buildQuery returns base wrapped with the cursor condition, order and limit.
+// Key values are passed as text, PostgreSQL converts them to the column types.
+func buildQuery(base string, baseArgs []any, keys []Key, cursor *token, limit int) (string, []any) {
+	backward := cursor != nil && cursor.Backward
+	args := slices.Clone(baseArgs)
+
+	var sb strings.Builder
+	sb.WriteString("SELECT * FROM (")
+	sb.WriteString(base)
+	sb.WriteString(") AS keyset_page")
+
+	if cursor != nil {
+		placeholders := make([]string, len(keys))
+		for i, v := range cursor.Values {
+			args = append(args, v)
+			placeholders[i] = "$" + strconv.Itoa(len(args))
+		}
+
+		sb.WriteString(" WHERE ")
+		sb.WriteString(condition(keys, placeholders, backward))
+	}
+
+	sb.WriteString(" ORDER BY ")
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(quote(k.Column))
+		if k.Desc != backward {
+			sb.WriteString(" DESC")
+		}
+	}
+
+	sb.WriteString(" LIMIT ")
+	sb.WriteString(strconv.Itoa(limit))
+
+	return sb.String(), args
+}
+
+// condition returns the predicate selecting rows after the cursor in the direction of reading.
+// Keys with the same direction use a row comparison, mixed directions are expanded.
+func condition(keys []Key, placeholders []string, backward bool) string {
+	op := func(k Key) string {
+		if k.Desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	sameDirection := !slices.ContainsFunc(keys, func(k Key) bool { return k.Desc != keys[0].Desc })
+	if sameDirection {
+		columns := make([]string, len(keys))
+		for i, k := range keys {
+			columns[i] = quote(k.Column)
+		}
+
+		return "(" + strings.Join(columns, ", ") + ") " + op(keys[0]) + " (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	terms := make([]string, len(keys))
+	for i, k := range keys {
+		parts := make([]string, 0, i+1)
+		for j := range i {
+			parts = append(parts, quote(keys[j].Column)+" = "+placeholders[j])
+		}
+		parts = append(parts, quote(k.Column)+" "+op(k)+" "+placeholders[i])
+		terms[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+func quote(column string) string {
+	return pgx.Identifier{column}.Sanitize()
+}
+
+// encode returns signed cursor token of key values.
+func (p *Paginator) encode(backward bool, values []any, keys []Key) (string, error) {
+	if len(values) != len(keys) {
+		return "", fmt.Errorf("KeyOf returned %d values for %d keys", len(values), len(keys))
+	}
+
+	t := token{Backward: backward, Values: make([]string, len(values))}
+	for i, v := range values {
+		text, err := textValue(v)
+		if err != nil {
+			return "", fmt.Errorf("key %s: %w", keys[i].Column, err)
+		}
+		t.Values[i] = text
+	}
+
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(p.sign(payload, keys)), nil
+}
+
+// decode verifies cursor and returns its token.
+func (p *Paginator) decode(cursor string, keys []Key) (token, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return token{}, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return token{}, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, p.sign(payload, keys)) {
+		return token{}, ErrInvalidCursor
+	}
+
+	var t token
+	if err := json.Unmarshal(payload, &t); err != nil || len(t.Values) != len(keys) {
+		return token{}, ErrInvalidCursor
+	}
+
+	return t, nil
+}
+
+// sign returns MAC of payload bound to keys, so a cursor can't be used with other keys.
+func (p *Paginator) sign(payload []byte, keys []Key) []byte {
+	h := hmac.New(sha256.New, p.secret)
+	h.Write(payload)
+	for _, k := range keys {
+		h.Write([]byte{0})
+		h.Write([]byte(k.Column))
+		if k.Desc {
+			h.Write([]byte{1})
+		}
+	}
+
+	return h.Sum(nil)
+}
+
+// textValue returns PostgreSQL text representation of a key value.
+func textValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case nil:
+		return "", errors.New("null key values are not supported")
+	default:
+		return "", fmt.Errorf("unsupported key type %T", v)
+	}
+}
@@ -0,0 +1,201 @@
+package keyset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/matryer/is"
+)
+
+const testSecret = "0123456789abcdef"
+
+func TestBuildQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []Key
+		cursor   *token
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "first page",
+			keys:     []Key{Asc("created_at"), Asc("id")},
+			wantSQL:  `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS keyset_page ORDER BY "created_at", "id" LIMIT 11`,
+			wantArgs: []any{7},
+		},
+		{
+			name:     "next page",
+			keys:     []Key{Desc("created_at"), Desc("id")},
+			cursor:   &token{Values: []string{"2024-01-02T00:00:00Z", "5"}},
+			wantSQL:  `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS keyset_page WHERE ("created_at", "id") < ($2, $3) ORDER BY "created_at" DESC, "id" DESC LIMIT 11`,
+			wantArgs: []any{7, "2024-01-02T00:00:00Z", "5"},
+		},
+		{
+			name:     "prev page",
+			keys:     []Key{Asc("id")},
+			cursor:   &token{Backward: true, Values: []string{"5"}},
+			wantSQL:  `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS keyset_page WHERE ("id") < ($2) ORDER BY "id" DESC LIMIT 11`,
+			wantArgs: []any{7, "5"},
+		},
+		{
+			name:     "mixed directions",
+			keys:     []Key{Desc("score"), Asc("id")},
+			cursor:   &token{Values: []string{"10", "5"}},
+			wantSQL:  `SELECT * FROM (SELECT * FROM users WHERE org = $1) AS keyset_page WHERE (("score" < $2) OR ("score" = $2 AND "id" > $3)) ORDER BY "score" DESC, "id" LIMIT 11`,
+			wantArgs: []any{7, "10", "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := is.New(t)
+
+			sql, args := buildQuery("SELECT * FROM users WHERE org = $1", []any{7}, tt.keys, tt.cursor, 11)
+			is.Equal(sql, tt.wantSQL)
+			is.Equal(args, tt.wantArgs)
+		})
+	}
+}
+
+func TestCursorToken(t *testing.T) {
+	is := is.New(t)
+
+	_, err := New([]byte("short"))
+	is.Equal(err, ErrShortSecret)
+
+	p, err := New([]byte(testSecret))
+	is.NoErr(err)
+
+	keys := []Key{Asc("created_at"), Asc("id")}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	cursor, err := p.encode(true, []any{created, int64(42)}, keys)
+	is.NoErr(err)
+
+	tok, err := p.decode(cursor, keys)
+	is.NoErr(err)
+	is.Equal(tok, token{Backward: true, Values: []string{"2024-01-02T03:04:05.000006Z", "42"}})
+
+	_, err = p.decode(cursor[:len(cursor)-2]+"AA", keys)
+	is.True(errors.Is(err, ErrInvalidCursor)) // tampered signature
+
+	_, err = p.decode("e30"+cursor[3:], keys)
+	is.True(errors.Is(err, ErrInvalidCursor)) // tampered payload
+
+	_, err = p.decode(cursor, []Key{Desc("created_at"), Asc("id")})
+	is.True(errors.Is(err, ErrInvalidCursor)) // cursor is bound to keys
+
+	other, err := New([]byte("fedcba9876543210"))
+	is.NoErr(err)
+	_, err = other.decode(cursor, keys)
+	is.True(errors.Is(err, ErrInvalidCursor)) // cursor is bound to secret
+
+	_, err = p.encode(false, []any{nil, 1}, keys)
+	is.True(err != nil) // null keys are not supported
+}
+
+// pageSelector returns rows and records the query.
+type pageSelector struct {
+	rows []int
+	sql  string
+}
+
+func (s *pageSelector) Select(_ context.Context, dst any, sql string, _ ...any) error {
+	s.sql = sql
+	*dst.(*[]int) = append([]int(nil), s.rows...)
+	return nil
+}
+
+func TestFetch(t *testing.T) {
+	is := is.New(t)
+
+	p, err := New([]byte(testSecret))
+	is.NoErr(err)
+
+	query := Query[int]{
+		SQL:   "SELECT id FROM numbers",
+		Keys:  []Key{Asc("id")},
+		KeyOf: func(id int) []any { return []any{id} },
+		Limit: 2,
+	}
+
+	s := &pageSelector{rows: []int{1, 2, 3}}
+	first, err := Fetch(t.Context(), s, p, query)
+	is.NoErr(err)
+	is.Equal(first.Items, []int{1, 2})
+	is.Equal(first.Prev, "") // first page has no previous one
+	is.True(first.Next != "")
+
+	query.Cursor = first.Next
+	s.rows = []int{3}
+	second, err := Fetch(t.Context(), s, p, query)
+	is.NoErr(err)
+	is.Equal(second.Items, []int{3})
+	is.Equal(second.Next, "") // no more rows
+	is.True(second.Prev != "")
+
+	query.Cursor = second.Prev
+	s.rows = []int{2, 1}
+	back, err := Fetch(t.Context(), s, p, query)
+	is.NoErr(err)
+	is.Equal(s.sql, `SELECT * FROM (SELECT id FROM numbers) AS keyset_page WHERE ("id") < ($1) ORDER BY "id" DESC LIMIT 3`)
+	is.Equal(back.Items, []int{1, 2}) // rows read backward are returned in key order
+	is.Equal(back.Prev, "")
+	is.True(back.Next != "")
+
+	query.Cursor = "garbage"
+	_, err = Fetch(t.Context(), s, p, query)
+	is.True(errors.Is(err, ErrInvalidCursor))
+}
+
+func TestFetchDatabase(t *testing.T) {
+	conn.TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, c *pgx.Conn) {
+		is := is.New(t)
+
+		type event struct {
+			ID    int
+			Score int
+		}
+
+		p, err := New([]byte(testSecret))
+		is.NoErr(err)
+
+		query := Query[event]{
+			SQL:   `SELECT id, id % 3 AS score FROM generate_series(1, $1::int) AS id`,
+			Args:  []any{7},
+			Keys:  []Key{Desc("score"), Asc("id")},
+			KeyOf: func(e event) []any { return []any{e.Score, e.ID} },
+			Limit: 3,
+		}
+
+		querier := conn.WrapConn(c, pgxscan.DefaultAPI)
+		var ids [][]int
+		pages := []Page[event]{}
+		for {
+			page, err := Fetch(ctx, querier, p, query)
+			is.NoErr(err)
+			pages = append(pages, page)
+
+			var pageIDs []int
+			for _, e := range page.Items {
+				pageIDs = append(pageIDs, e.ID)
+			}
+			ids = append(ids, pageIDs)
+
+			if page.Next == "" {
+				break
+			}
+			query.Cursor = page.Next
+		}
+		is.Equal(ids, [][]int{{2, 5, 1}, {4, 7, 3}, {6}})
+
+		query.Cursor = pages[2].Prev
+		page, err := Fetch(ctx, querier, p, query)
+		is.NoErr(err)
+		is.Equal(page.Items, []event{{ID: 4, Score: 1}, {ID: 7, Score: 1}, {ID: 3, Score: 0}})
+	})
+}
@@ -0,0 +1,220 @@
+// Package querylog provides a conn.Interceptor logging slow and failed queries with log/slog.
+package querylog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	defaultSlowThreshold = 500 * time.Millisecond
+	defaultMaxSQLLength  = 1024
+
+	// Redacted replaces arguments hidden by a Redactor.
+	Redacted = "[REDACTED]"
+)
+
+// Redactor returns the value of argument at index of sql to log.
+type Redactor func(sql string, index int, arg any) any
+
+// RedactAll hides every argument, it is the default.
+func RedactAll(string, int, any) any {
+	return Redacted
+}
+
+// RedactNone logs arguments as is.
+func RedactNone(_ string, _ int, arg any) any {
+	return arg
+}
+
+// RedactPositions hides arguments at zero-based positions and logs the rest as is.
+func RedactPositions(positions ...int) Redactor {
+	hidden := make(map[int]struct{}, len(positions))
+	for _, p := range positions {
+		hidden[p] = struct{}{}
+	}
+
+	return func(_ string, index int, arg any) any {
+		if _, ok := hidden[index]; ok {
+			return Redacted
+		}
+		return arg
+	}
+}
+
+// Options for query logging.
+type Options struct {
+	// SlowThreshold is the duration above which a successful query is logged at warn level.
+	// Zero disables slow query logging.
+	SlowThreshold time.Duration
+	// Debug logs every query at debug level.
+	Debug bool
+	// Redactor decides how arguments are logged, all arguments are hidden by default.
+	Redactor Redactor
+	// MaxSQLLength is the number of bytes of SQL logged, longer statements are truncated.
+	// Zero disables truncation.
+	MaxSQLLength int
+}
+
+// Option func.
+type Option func(*Options)
+
+// WithSlowThreshold sets duration above which a query is logged as slow, zero disables slow query logging.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowThreshold = d
+	}
+}
+
+// WithDebug logs every query at debug level.
+func WithDebug() Option {
+	return func(o *Options) {
+		o.Debug = true
+	}
+}
+
+// WithRedactor sets how arguments are logged.
+func WithRedactor(redactor Redactor) Option {
+	return func(o *Options) {
+		if redactor != nil {
+			o.Redactor = redactor
+		}
+	}
+}
+
+// WithMaxSQLLength sets the number of bytes of SQL logged, zero disables truncation.
+func WithMaxSQLLength(n int) Option {
+	return func(o *Options) {
+		o.MaxSQLLength = n
+	}
+}
+
+// New returns an interceptor logging with logger failed queries at error level, slow queries at warn level
+// and, if enabled, every query at debug level.
+// Tx calls are logged only when the transaction fails to begin or commit, e.g. with a serialization failure,
+// queries run in the transaction are logged on their own.
+// Install it with conn.WithInterceptors or cluster.WithInterceptors.
+func New(logger *slog.Logger, opts ...Option) conn.Interceptor {
+	options := &Options{
+		SlowThreshold: defaultSlowThreshold,
+		Redactor:      RedactAll,
+		MaxSQLLength:  defaultMaxSQLLength,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return func(ctx context.Context, call *conn.Call, next conn.Handler) error {
+		start := time.Now()
+		err := next(ctx, call)
+		duration := time.Since(start)
+
+		if call.Method == conn.MethodTx {
+			if call.TxErr != nil && !errors.Is(call.TxErr, context.Canceled) && logger.Enabled(ctx, slog.LevelError) {
+				logger.LogAttrs(ctx, slog.LevelError, "transaction failed", options.attrs(call, duration, call.TxErr)...)
+			}
+			return err
+		}
+
+		var (
+			level slog.Level
+			msg   string
+		)
+		switch {
+		case err != nil && !errors.Is(err, context.Canceled):
+			level, msg = slog.LevelError, "query failed"
+		case err == nil && options.SlowThreshold > 0 && duration > options.SlowThreshold:
+			level, msg = slog.LevelWarn, "slow query"
+		case options.Debug:
+			level, msg = slog.LevelDebug, "query"
+		default:
+			return err
+		}
+
+		if !logger.Enabled(ctx, level) {
+			return err
+		}
+
+		logger.LogAttrs(ctx, level, msg, options.attrs(call, duration, err)...)
+		return err
+	}
+}
+
+// attrs returns log attributes of the call.
+func (o *Options) attrs(call *conn.Call, duration time.Duration, err error) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", string(call.Method)),
+		slog.String("sql", truncate(call.SQL, o.MaxSQLLength)),
+		slog.Duration("duration", duration),
+	}
+
+	if len(call.Args) > 0 {
+		args := make([]any, len(call.Args))
+		for i, arg := range call.Args {
+			args[i] = o.Redactor(call.SQL, i, arg)
+		}
+		attrs = append(attrs, slog.Any("args", args))
+	}
+
+	if call.Table != nil {
+		attrs = append(attrs, slog.String("table", call.Table.Sanitize()))
+	}
+
+	if (call.Method == conn.MethodExec || call.Method == conn.MethodCopy) && err == nil {
+		attrs = append(attrs, slog.Int64("rows_affected", call.RowsAffected))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			attrs = append(attrs, slog.Group("pg", pgErrorAttrs(pgErr)...))
+		}
+	}
+
+	return attrs
+}
+
+// pgErrorAttrs returns non-empty fields of err.
+func pgErrorAttrs(err *pgconn.PgError) []any {
+	attrs := []any{slog.String("code", err.Code)}
+	for _, field := range []struct{ key, value string }{
+		{"severity", err.Severity},
+		{"detail", err.Detail},
+		{"hint", err.Hint},
+		{"schema", err.SchemaName},
+		{"table", err.TableName},
+		{"column", err.ColumnName},
+		{"constraint", err.ConstraintName},
+	} {
+		if field.value != "" {
+			attrs = append(attrs, slog.String(field.key, field.value))
+		}
+	}
+
+	if err.Position > 0 {
+		attrs = append(attrs, slog.Int("position", int(err.Position)))
+	}
+
+	return attrs
+}
+
+// truncate cuts sql to at most n bytes on a rune boundary, n <= 0 keeps sql intact.
+func truncate(sql string, n int) string {
+	if n <= 0 || len(sql) <= n {
+		return sql
+	}
+
+	for n > 0 && !utf8.RuneStart(sql[n]) {
+		n--
+	}
+
+	return sql[:n] + "..."
+}
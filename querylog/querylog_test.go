@@ -0,0 +1,157 @@
+package querylog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/matryer/is"
+)
+
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func handler(d time.Duration, err error) conn.Handler {
+	return func(_ context.Context, call *conn.Call) error {
+		time.Sleep(d)
+		call.RowsAffected = 3
+		return err
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	newLogger := func(buf *bytes.Buffer) *slog.Logger {
+		return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	t.Run("failed query with pg error", func(t *testing.T) {
+		is := is.New(t)
+
+		var buf bytes.Buffer
+		interceptor := New(newLogger(&buf))
+
+		pgErr := &pgconn.PgError{
+			Severity:       "ERROR",
+			Code:           "23505",
+			Detail:         "Key (email)=(a@b.c) already exists.",
+			ConstraintName: "users_email_key",
+			TableName:      "users",
+			Position:       12,
+		}
+		call := &conn.Call{Method: conn.MethodExec, SQL: "INSERT INTO users (email) VALUES ($1)", Args: []any{"a@b.c"}}
+		is.Equal(interceptor(t.Context(), call, handler(0, pgErr)), pgErr)
+
+		entries := logEntries(t, &buf)
+		is.Equal(len(entries), 1)
+		entry := entries[0]
+		is.Equal(entry["level"], "ERROR")
+		is.Equal(entry["msg"], "query failed")
+		is.Equal(entry["method"], "Exec")
+		is.Equal(entry["args"], []any{Redacted}) // args are hidden by default
+		is.Equal(entry["pg"], map[string]any{
+			"code":       "23505",
+			"severity":   "ERROR",
+			"detail":     "Key (email)=(a@b.c) already exists.",
+			"table":      "users",
+			"constraint": "users_email_key",
+			"position":   float64(12),
+		})
+	})
+
+	t.Run("slow query", func(t *testing.T) {
+		is := is.New(t)
+
+		var buf bytes.Buffer
+		interceptor := New(newLogger(&buf), WithSlowThreshold(time.Millisecond), WithRedactor(RedactPositions(1)))
+
+		call := &conn.Call{Method: conn.MethodSelect, SQL: "SELECT * FROM users WHERE id = $1 AND token = $2", Args: []any{7, "secret"}}
+		is.NoErr(interceptor(t.Context(), call, handler(5*time.Millisecond, nil)))
+		is.NoErr(interceptor(t.Context(), call, handler(0, nil))) // fast query is not logged
+
+		entries := logEntries(t, &buf)
+		is.Equal(len(entries), 1)
+		is.Equal(entries[0]["level"], "WARN")
+		is.Equal(entries[0]["msg"], "slow query")
+		is.Equal(entries[0]["args"], []any{float64(7), Redacted})
+	})
+
+	t.Run("debug and truncation", func(t *testing.T) {
+		is := is.New(t)
+
+		var buf bytes.Buffer
+		interceptor := New(newLogger(&buf), WithDebug(), WithMaxSQLLength(10), WithRedactor(RedactNone))
+
+		is.NoErr(interceptor(t.Context(), &conn.Call{Method: conn.MethodExec, SQL: "DELETE FROM users"}, handler(0, nil)))
+		errBoom := errors.New("boom")
+		is.Equal(interceptor(t.Context(), &conn.Call{Method: conn.MethodTx}, handler(0, errBoom)), errBoom) // callback errors are not logged
+
+		entries := logEntries(t, &buf)
+		is.Equal(len(entries), 1)
+		is.Equal(entries[0]["level"], "DEBUG")
+		is.Equal(entries[0]["sql"], "DELETE FRO...")
+		is.Equal(entries[0]["rows_affected"], float64(3))
+	})
+
+	t.Run("failed commit", func(t *testing.T) {
+		is := is.New(t)
+
+		var buf bytes.Buffer
+		interceptor := New(newLogger(&buf))
+
+		serializationFailure := &pgconn.PgError{Severity: "ERROR", Code: "40001"}
+		commit := func(_ context.Context, call *conn.Call) error {
+			call.TxErr = serializationFailure
+			return serializationFailure
+		}
+		is.Equal(interceptor(t.Context(), &conn.Call{Method: conn.MethodTx}, commit), serializationFailure)
+
+		entries := logEntries(t, &buf)
+		is.Equal(len(entries), 1)
+		is.Equal(entries[0]["level"], "ERROR")
+		is.Equal(entries[0]["msg"], "transaction failed")
+		is.Equal(entries[0]["method"], "Tx")
+		is.Equal(entries[0]["pg"], map[string]any{"code": "40001", "severity": "ERROR"})
+	})
+
+	t.Run("disabled level", func(t *testing.T) {
+		is := is.New(t)
+
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		interceptor := New(logger, WithDebug())
+
+		is.NoErr(interceptor(t.Context(), &conn.Call{Method: conn.MethodGet, SQL: "SELECT 1"}, handler(0, nil)))
+		is.Equal(buf.Len(), 0)
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(truncate("SELECT 1", 0), "SELECT 1")
+	is.Equal(truncate("SELECT 1", 8), "SELECT 1")
+	is.Equal(truncate("SELECT 'дом'", 9), "SELECT '...") // multi-byte rune is not split
+}
@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MrEhbr/pgxext/v2/cluster"
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	_ conn.Querier   = &querier{}
+	_ cluster.Conn   = &clusterConn{}
+	_ conn.TxManager = &txManager{}
+)
+
+// queries are the methods conn.Querier and cluster.Conn have in common.
+type queries interface {
+	Select(ctx context.Context, dst any, sql string, args ...any) error
+	Get(ctx context.Context, dst any, sql string, args ...any) error
+	Exec(ctx context.Context, sql string, args ...any) (int64, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	SendBatch(ctx context.Context, b *conn.Batch) error
+	CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error)
+	Tx(ctx context.Context, f func(q conn.Querier) error, opts ...conn.TxOption) error
+}
+
+// traced starts a span for each call of next.
+type traced struct {
+	next   queries
+	tracer *Tracer
+}
+
+func (t traced) Select(ctx context.Context, dst any, sql string, args ...any) error {
+	ctx, span := t.tracer.start(ctx, "Select", sql)
+	err := t.next.Select(ctx, dst, sql, args...)
+	end(span, err)
+
+	return err
+}
+
+func (t traced) Get(ctx context.Context, dst any, sql string, args ...any) error {
+	ctx, span := t.tracer.start(ctx, "Get", sql)
+	err := t.next.Get(ctx, dst, sql, args...)
+	end(span, err)
+
+	return err
+}
+
+func (t traced) Exec(ctx context.Context, sql string, args ...any) (int64, error) {
+	ctx, span := t.tracer.start(ctx, "Exec", sql)
+	rows, err := t.next.Exec(ctx, sql, args...)
+	if err == nil {
+		span.SetAttributes(rowsAffectedKey.Int64(rows))
+	}
+	end(span, err)
+
+	return rows, err
+}
+
+// Query starts a span ended once the query is sent, reading rows is not included.
+func (t traced) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	ctx, span := t.tracer.start(ctx, "Query", sql)
+	rows, err := t.next.Query(ctx, sql, args...)
+	end(span, err)
+
+	return rows, err
+}
+
+func (t traced) SendBatch(ctx context.Context, b *conn.Batch) error {
+	ctx, span := t.tracer.start(ctx, "SendBatch", "")
+	span.SetAttributes(batchSizeKey.Int(b.Len()))
+	err := t.next.SendBatch(ctx, b)
+	end(span, err)
+
+	return err
+}
+
+func (t traced) CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error) {
+	ctx, span := t.tracer.start(ctx, "CopyFrom", "")
+	span.SetAttributes(collectionKey.String(table.Sanitize()))
+	copied, err := t.next.CopyFrom(ctx, table, rows)
+	if err == nil {
+		span.SetAttributes(rowsAffectedKey.Int64(copied))
+	}
+	end(span, err)
+
+	return copied, err
+}
+
+// Tx starts a span for the transaction, queries of f are traced as its children.
+func (t traced) Tx(ctx context.Context, f func(q conn.Querier) error, opts ...conn.TxOption) error {
+	ctx, span := t.tracer.start(ctx, "Tx", "")
+
+	var fErr error
+	err := t.next.Tx(ctx, func(q conn.Querier) error {
+		fErr = f(t.tracer.Querier(q))
+		return fErr
+	}, opts...)
+
+	outcome := cluster.TxCommit
+	switch {
+	case fErr != nil:
+		outcome = cluster.TxRollback
+	case err != nil:
+		outcome = cluster.TxError
+	}
+	span.SetAttributes(txOutcomeKey.String(string(outcome)))
+	end(span, err)
+
+	return err
+}
+
+type querier struct {
+	traced
+	conn conn.Querier
+}
+
+// Querier returns q that starts a span for Select, Get, Exec, Query, SendBatch, CopyFrom and Tx calls.
+func (t *Tracer) Querier(q conn.Querier) conn.Querier {
+	return &querier{traced: traced{next: q, tracer: t}, conn: q}
+}
+
+func (q *querier) Conn(ctx context.Context) conn.PgxConn {
+	return q.conn.Conn(ctx)
+}
+
+func (q *querier) ScanAPI() *pgxscan.API {
+	return q.conn.ScanAPI()
+}
+
+type clusterConn struct {
+	traced
+	conn cluster.Conn
+}
+
+// Cluster returns c that starts a span for Select, Get, Exec, Query, SendBatch, CopyFrom and Tx calls,
+// queriers returned by Primary and Replica are traced as well.
+// If c accepts observers, spans get the role and address of the node that served the call.
+func (t *Tracer) Cluster(c cluster.Conn) cluster.Conn {
+	if o, ok := c.(interface{ AddObserver(o cluster.Observer) }); ok {
+		o.AddObserver(t)
+	}
+
+	return &clusterConn{traced: traced{next: c, tracer: t}, conn: c}
+}
+
+func (c *clusterConn) Primary() conn.Querier {
+	return c.tracer.Querier(c.conn.Primary())
+}
+
+func (c *clusterConn) Replica() conn.Querier {
+	return c.tracer.Querier(c.conn.Replica())
+}
+
+func (c *clusterConn) ScanAPI() *pgxscan.API {
+	return c.conn.ScanAPI()
+}
+
+func (c *clusterConn) Ping(ctx context.Context) error {
+	return c.conn.Ping(ctx)
+}
+
+func (c *clusterConn) Close() error {
+	return c.conn.Close()
+}
+
+type txManager struct {
+	manager conn.TxManager
+	tracer  *Tracer
+}
+
+// TxManager returns m that starts a span for each transaction and ends it on commit or rollback.
+// Queries traced with the returned context are children of the span.
+func (t *Tracer) TxManager(m conn.TxManager) conn.TxManager {
+	return &txManager{manager: m, tracer: t}
+}
+
+func (m *txManager) NewTx(ctx context.Context, opts ...conn.TxOption) (context.Context, func() error, func() error, error) {
+	ctx, span := m.tracer.start(ctx, "Tx", "")
+	txCtx, commit, rollback, err := m.manager.NewTx(ctx, opts...)
+	if err != nil {
+		span.SetAttributes(txOutcomeKey.String(string(cluster.TxError)))
+		end(span, err)
+		return txCtx, nil, nil, err
+	}
+
+	// only the first of commit and rollback ends the span, rollback is usually deferred after commit.
+	var once sync.Once
+	finish := func(err error, outcome cluster.TxOutcome) {
+		once.Do(func() {
+			if err != nil {
+				outcome = cluster.TxError
+			}
+			span.SetAttributes(txOutcomeKey.String(string(outcome)))
+			end(span, err)
+		})
+	}
+
+	tracedCommit := func() error {
+		err := commit()
+		finish(err, cluster.TxCommit)
+		return err
+	}
+
+	tracedRollback := func() error {
+		err := rollback()
+		finish(err, cluster.TxRollback)
+		return err
+	}
+
+	return txCtx, tracedCommit, tracedRollback, nil
+}
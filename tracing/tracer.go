@@ -0,0 +1,172 @@
+// Package tracing adds OpenTelemetry spans to conn.Querier, cluster.Conn, conn.TxManager and pgx connections.
+// Tracing is opt-in: nothing is traced unless a querier is wrapped or the Tracer is set as pgx QueryTracer.
+package tracing
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/MrEhbr/pgxext/v2/cluster"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/MrEhbr/pgxext/v2/tracing"
+
+	dbSystemKey     = attribute.Key("db.system")
+	dbStatementKey  = attribute.Key("db.statement")
+	dbOperationKey  = attribute.Key("db.operation")
+	rowsAffectedKey = attribute.Key("db.rows_affected")
+	serverAddrKey   = attribute.Key("server.address")
+	serverPortKey   = attribute.Key("server.port")
+	nodeRoleKey     = attribute.Key("pgxext.node.role")
+	txOutcomeKey    = attribute.Key("pgxext.tx.outcome")
+	batchSizeKey    = attribute.Key("db.operation.batch.size")
+	collectionKey   = attribute.Key("db.collection.name")
+)
+
+var (
+	_ pgx.QueryTracer  = &Tracer{}
+	_ cluster.Observer = &Tracer{}
+)
+
+// Tracer starts spans for database calls.
+type Tracer struct {
+	tracer trace.Tracer
+	redact func(sql string) string
+	attrs  []attribute.KeyValue
+}
+
+// Options for tracer.
+type Options struct {
+	// TracerProvider creates the tracer, the global provider is used by default.
+	TracerProvider trace.TracerProvider
+	// Redact returns db.statement value for sql, empty result omits the attribute.
+	// Statements are recorded as is by default.
+	Redact func(sql string) string
+	// Attributes are added to every span.
+	Attributes []attribute.KeyValue
+}
+
+// Option func.
+type Option func(*Options)
+
+// WithTracerProvider sets provider of the tracer.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *Options) {
+		if provider != nil {
+			o.TracerProvider = provider
+		}
+	}
+}
+
+// WithStatementRedactor sets function returning db.statement value for sql, empty result omits the attribute.
+func WithStatementRedactor(redact func(sql string) string) Option {
+	return func(o *Options) {
+		if redact != nil {
+			o.Redact = redact
+		}
+	}
+}
+
+// WithAttributes sets attributes added to every span.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(o *Options) {
+		o.Attributes = append(o.Attributes, attrs...)
+	}
+}
+
+// New returns a tracer configured by opts.
+func New(opts ...Option) *Tracer {
+	options := &Options{
+		TracerProvider: otel.GetTracerProvider(),
+		Redact:         func(sql string) string { return sql },
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return &Tracer{
+		tracer: options.TracerProvider.Tracer(instrumentationName),
+		redact: options.Redact,
+		attrs:  append([]attribute.KeyValue{dbSystemKey.String("postgresql")}, options.Attributes...),
+	}
+}
+
+// start starts a client span named operation for sql, sql may be empty.
+func (t *Tracer) start(ctx context.Context, operation, sql string) (context.Context, trace.Span) {
+	attrs := make([]attribute.KeyValue, 0, len(t.attrs)+2)
+	attrs = append(attrs, t.attrs...)
+	attrs = append(attrs, dbOperationKey.String(operation))
+	if sql != "" {
+		if statement := t.redact(sql); statement != "" {
+			attrs = append(attrs, dbStatementKey.String(statement))
+		}
+	}
+
+	return t.tracer.Start(ctx, "pgxext."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// end records err and ends span.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Observe adds node of a cluster call to the span in ctx, it implements cluster.Observer.
+func (t *Tracer) Observe(ctx context.Context, e cluster.Event) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	span.SetAttributes(nodeRoleKey.String(string(e.Role)))
+	span.SetAttributes(serverAttributes(e.Node)...)
+}
+
+// TraceQueryStart starts a span for a query executed by pgx, it implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryStart(ctx context.Context, c *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, span := t.start(ctx, "query", data.SQL)
+	if c != nil {
+		config := c.Config()
+		span.SetAttributes(serverAttributes(net.JoinHostPort(config.Host, strconv.Itoa(int(config.Port))))...)
+	}
+
+	return ctx
+}
+
+// TraceQueryEnd ends the span started by TraceQueryStart, it implements pgx.QueryTracer.
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err == nil {
+		span.SetAttributes(rowsAffectedKey.Int64(data.CommandTag.RowsAffected()))
+	}
+
+	end(span, data.Err)
+}
+
+// serverAttributes returns server.address and server.port of node in host:port form.
+func serverAttributes(node string) []attribute.KeyValue {
+	host, port, err := net.SplitHostPort(node)
+	if err != nil {
+		return []attribute.KeyValue{serverAddrKey.String(node)}
+	}
+
+	attrs := []attribute.KeyValue{serverAddrKey.String(host)}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, serverPortKey.Int(p))
+	}
+
+	return attrs
+}
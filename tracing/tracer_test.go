@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MrEhbr/pgxext/v2/cluster"
+	"github.com/MrEhbr/pgxext/v2/conn"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/matryer/is"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// stubQuerier returns err from every call and 2 affected rows from Exec.
+type stubQuerier struct {
+	err error
+}
+
+func (q *stubQuerier) Select(context.Context, any, string, ...any) error { return q.err }
+func (q *stubQuerier) Get(context.Context, any, string, ...any) error    { return q.err }
+func (q *stubQuerier) Exec(context.Context, string, ...any) (int64, error) {
+	return 2, q.err
+}
+
+func (q *stubQuerier) Tx(_ context.Context, f func(q conn.Querier) error, _ ...conn.TxOption) error {
+	if err := f(q); err != nil {
+		return err
+	}
+	return q.err
+}
+func (q *stubQuerier) Query(context.Context, string, ...any) (pgx.Rows, error)      { return nil, q.err }
+func (q *stubQuerier) SendBatch(context.Context, *conn.Batch) error                 { return q.err }
+func (q *stubQuerier) CopyFrom(context.Context, pgx.Identifier, any) (int64, error) { return 0, q.err }
+func (q *stubQuerier) Conn(context.Context) conn.PgxConn                            { return nil }
+func (q *stubQuerier) ScanAPI() *pgxscan.API                                        { return pgxscan.DefaultAPI }
+
+type stubTxManager struct{}
+
+func (stubTxManager) NewTx(ctx context.Context, _ ...conn.TxOption) (context.Context, func() error, func() error, error) {
+	closed := false
+	finish := func() error {
+		if closed {
+			return pgx.ErrTxClosed
+		}
+		closed = true
+		return nil
+	}
+
+	return ctx, finish, finish, nil
+}
+
+func newTestTracer(opts ...Option) (*Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	return New(append([]Option{WithTracerProvider(provider)}, opts...)...), recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+
+	return attrs
+}
+
+func TestQuerier(t *testing.T) {
+	t.Run("spans with attributes", func(t *testing.T) {
+		is := is.New(t)
+
+		tracer, recorder := newTestTracer(WithStatementRedactor(strings.ToUpper))
+		q := tracer.Querier(&stubQuerier{})
+
+		var n int
+		is.NoErr(q.Get(t.Context(), &n, "select 1"))
+		rows, err := q.Exec(t.Context(), "delete from users")
+		is.NoErr(err)
+		is.Equal(rows, int64(2))
+
+		spans := recorder.Ended()
+		is.Equal(len(spans), 2)
+
+		is.Equal(spans[0].Name(), "pgxext.Get")
+		attrs := attributes(spans[0])
+		is.Equal(attrs[dbSystemKey].AsString(), "postgresql")
+		is.Equal(attrs[dbStatementKey].AsString(), "SELECT 1") // statement is redacted
+
+		is.Equal(spans[1].Name(), "pgxext.Exec")
+		is.Equal(attributes(spans[1])[rowsAffectedKey].AsInt64(), int64(2))
+	})
+
+	t.Run("tx children and outcome", func(t *testing.T) {
+		is := is.New(t)
+
+		tracer, recorder := newTestTracer(WithStatementRedactor(func(string) string { return "" }))
+		q := tracer.Querier(&stubQuerier{})
+
+		errAbort := errors.New("abort")
+		err := q.Tx(t.Context(), func(tx conn.Querier) error {
+			_, err := tx.Exec(t.Context(), "delete from users")
+			is.NoErr(err)
+			return errAbort
+		})
+		is.Equal(err, errAbort)
+
+		spans := recorder.Ended()
+		is.Equal(len(spans), 2)
+
+		is.Equal(spans[0].Name(), "pgxext.Exec")
+		_, ok := attributes(spans[0])[dbStatementKey]
+		is.True(!ok) // empty redaction omits the statement
+
+		is.Equal(spans[1].Name(), "pgxext.Tx")
+		is.Equal(attributes(spans[1])[txOutcomeKey].AsString(), "rollback")
+		is.Equal(spans[1].Status().Code, codes.Error)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		is := is.New(t)
+
+		tracer, recorder := newTestTracer()
+		q := tracer.Querier(&stubQuerier{err: errors.New("boom")})
+
+		is.True(q.Tx(t.Context(), func(conn.Querier) error { return nil }) != nil)
+
+		spans := recorder.Ended()
+		is.Equal(len(spans), 1)
+		is.Equal(attributes(spans[0])[txOutcomeKey].AsString(), "error")
+		is.Equal(spans[0].Status(), sdktrace.Status{Code: codes.Error, Description: "boom"})
+		is.Equal(len(spans[0].Events()), 1) // error is recorded
+	})
+}
+
+func TestTxManager(t *testing.T) {
+	is := is.New(t)
+
+	tracer, recorder := newTestTracer()
+	manager := tracer.TxManager(stubTxManager{})
+
+	_, commit, rollback, err := manager.NewTx(t.Context())
+	is.NoErr(err)
+	is.NoErr(commit())
+	is.True(errors.Is(rollback(), pgx.ErrTxClosed))
+
+	spans := recorder.Ended()
+	is.Equal(len(spans), 1) // rollback after commit does not end the span again
+	is.Equal(spans[0].Name(), "pgxext.Tx")
+	is.Equal(attributes(spans[0])[txOutcomeKey].AsString(), "commit")
+	is.Equal(spans[0].Status().Code, codes.Unset)
+}
+
+func TestObserve(t *testing.T) {
+	is := is.New(t)
+
+	tracer, recorder := newTestTracer()
+	ctx, span := tracer.start(t.Context(), "Select", "SELECT 1")
+	tracer.Observe(ctx, cluster.Event{Node: "10.0.0.2:5433", Role: cluster.RoleReplica, Method: cluster.MethodSelect})
+	span.End()
+
+	attrs := attributes(recorder.Ended()[0])
+	is.Equal(attrs[nodeRoleKey].AsString(), "replica")
+	is.Equal(attrs[serverAddrKey].AsString(), "10.0.0.2")
+	is.Equal(attrs[serverPortKey].AsInt64(), int64(5433))
+}
+
+func TestQueryTracer(t *testing.T) {
+	is := is.New(t)
+
+	tracer, recorder := newTestTracer()
+	ctx := tracer.TraceQueryStart(t.Context(), nil, pgx.TraceQueryStartData{SQL: "DELETE FROM users"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("DELETE 3")})
+
+	spans := recorder.Ended()
+	is.Equal(len(spans), 1)
+	is.Equal(spans[0].Name(), "pgxext.query")
+	attrs := attributes(spans[0])
+	is.Equal(attrs[dbStatementKey].AsString(), "DELETE FROM users")
+	is.Equal(attrs[rowsAffectedKey].AsInt64(), int64(3))
+}
+
+func TestCluster(t *testing.T) {
+	is := is.New(t)
+
+	db, err := cluster.Open([]string{"host=127.0.0.1 port=1 connect_timeout=1", "host=127.0.0.1 port=2 connect_timeout=1"})
+	is.NoErr(err)
+	defer db.Close()
+
+	tracer, recorder := newTestTracer()
+	traced := tracer.Cluster(db)
+
+	_, err = traced.Exec(t.Context(), "DELETE FROM users")
+	is.True(err != nil) // primary is unreachable
+
+	spans := recorder.Ended()
+	is.Equal(len(spans), 1)
+	attrs := attributes(spans[0])
+	is.Equal(attrs[nodeRoleKey].AsString(), "primary") // node reported by the cluster
+	is.Equal(attrs[serverPortKey].AsInt64(), int64(1))
+	is.Equal(spans[0].Status().Code, codes.Error)
+}
@@ -27,11 +27,16 @@ type (
 		tx      pgx.Tx
 		cluster cluster.Conn
 		scanAPI *pgxscan.API
+		opts    []conn.Option
 	}
 )
 
 func New(cluster *cluster.Cluster) *txdbCluster {
-	return &txdbCluster{cluster: cluster, scanAPI: cluster.ScanAPI()}
+	return &txdbCluster{
+		cluster: cluster,
+		scanAPI: cluster.ScanAPI(),
+		opts:    []conn.Option{conn.WithInterceptors(cluster.Interceptors()...)},
+	}
 }
 
 // Close rollback current transaction and close physical connection.
@@ -70,7 +75,7 @@ func (c *txdbCluster) Select(ctx context.Context, dst any, sql string, args ...a
 	if err != nil {
 		return err
 	}
-	return conn.WrapConn(tx, c.scanAPI).Select(ctx, dst, sql, args...)
+	return conn.WrapConn(tx, c.scanAPI, c.opts...).Select(ctx, dst, sql, args...)
 }
 
 func (c *txdbCluster) Get(ctx context.Context, dst any, sql string, args ...any) error {
@@ -82,7 +87,7 @@ func (c *txdbCluster) Get(ctx context.Context, dst any, sql string, args ...any)
 		return err
 	}
 
-	return conn.WrapConn(tx, c.scanAPI).Get(ctx, dst, sql, args...)
+	return conn.WrapConn(tx, c.scanAPI, c.opts...).Get(ctx, dst, sql, args...)
 }
 
 func (c *txdbCluster) Tx(ctx context.Context, f func(n conn.Querier) error, opts ...conn.TxOption) error {
@@ -94,7 +99,7 @@ func (c *txdbCluster) Tx(ctx context.Context, f func(n conn.Querier) error, opts
 		return err
 	}
 
-	return conn.WrapConn(tx, c.scanAPI).Tx(ctx, f, opts...)
+	return conn.WrapConn(tx, c.scanAPI, c.opts...).Tx(ctx, f, opts...)
 }
 
 func (c *txdbCluster) Begin(ctx context.Context) (pgx.Tx, error) {
@@ -111,7 +116,44 @@ func (c *txdbCluster) Exec(ctx context.Context, sql string, args ...any) (int64,
 	if err != nil {
 		return 0, err
 	}
-	return conn.WrapConn(tx, c.scanAPI).Exec(ctx, sql, args...)
+	return conn.WrapConn(tx, c.scanAPI, c.opts...).Exec(ctx, sql, args...)
+}
+
+func (c *txdbCluster) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.txLock.Lock()
+	defer c.txLock.Unlock()
+
+	tx, err := c.beginOnce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.WrapConn(tx, c.scanAPI, c.opts...).Query(ctx, sql, args...)
+}
+
+func (c *txdbCluster) SendBatch(ctx context.Context, b *conn.Batch) error {
+	c.txLock.Lock()
+	defer c.txLock.Unlock()
+
+	tx, err := c.beginOnce(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.WrapConn(tx, c.scanAPI, c.opts...).SendBatch(ctx, b)
+}
+
+func (c *txdbCluster) CopyFrom(ctx context.Context, table pgx.Identifier, rows any) (int64, error) {
+	c.txLock.Lock()
+	defer c.txLock.Unlock()
+
+	tx, err := c.beginOnce(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return conn.WrapConn(tx, c.scanAPI, c.opts...).CopyFrom(ctx, table, rows)
+}
+
+func (c *txdbCluster) ScanAPI() *pgxscan.API {
+	return c.scanAPI
 }
 
 func (c *txdbCluster) Primary() conn.Querier {
@@ -123,7 +165,7 @@ func (c *txdbCluster) Primary() conn.Querier {
 		panic(err)
 	}
 
-	return conn.WrapConn(tx, c.scanAPI)
+	return conn.WrapConn(tx, c.scanAPI, c.opts...)
 }
 
 func (c *txdbCluster) Replica() conn.Querier {
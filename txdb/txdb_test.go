@@ -115,4 +115,55 @@ func TestTxDB(t *testing.T) {
 			its.Equal(count, 0)
 		})
 	})
+
+	t.Run("cursor", func(t *testing.T) {
+		conn.TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, pgxConn *pgx.Conn) {
+			its := is.New(t)
+
+			db, err := cluster.Open([]string{pgxConn.Config().ConnString()})
+			its.NoErr(err)
+
+			_, err = db.Exec(ctx, `CREATE TEMP TABLE foo_cursor (value INT)`)
+			its.NoErr(err)
+
+			txdb := New(db)
+			defer txdb.Close()
+
+			_, err = txdb.Exec(ctx, `INSERT INTO foo_cursor (value) SELECT generate_series(1, 5)`)
+			its.NoErr(err)
+
+			// the loop body only collects chunks, calls on txdb would wait for the lock held by the cursor
+			var chunks [][]int
+			for chunk, err := range conn.Cursor[int](ctx, txdb, 2, `SELECT value FROM foo_cursor ORDER BY value`) {
+				its.NoErr(err)
+				chunks = append(chunks, chunk)
+			}
+			its.Equal(chunks, [][]int{{1, 2}, {3, 4}, {5}}) // uncommitted rows of the shared transaction are read
+
+			var count int
+			err = txdb.Get(ctx, &count, `SELECT COUNT(*) FROM foo_cursor`)
+			its.NoErr(err) // txdb is usable after the loop
+			its.Equal(count, 5)
+		})
+	})
+
+	t.Run("transaction hooks", func(t *testing.T) {
+		conn.TestRunner().RunTest(t.Context(), t, func(ctx context.Context, t testing.TB, pgxConn *pgx.Conn) {
+			its := is.New(t)
+
+			db, err := cluster.Open([]string{pgxConn.Config().ConnString()})
+			its.NoErr(err)
+
+			txdb := New(db)
+			defer txdb.Close()
+
+			err = txdb.Tx(ctx, func(q conn.Querier) error {
+				// the savepoint is released before txdb rolls the transaction back
+				err := conn.OnCommit(conn.QuerierTxContext(ctx, q), func(context.Context) {})
+				its.True(errors.Is(err, conn.ErrNoTx))
+				return nil
+			})
+			its.NoErr(err)
+		})
+	})
 }